@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ABTest routes SplitPercent% of plan requests for a persona to VariantBName.
+type ABTest struct {
+	VariantBName string `json:"variant_b_name" example:"brand-x-b"`
+	SplitPercent int    `json:"split_percent" example:"50"`
+}
+
+type abTestStore struct {
+	mu    sync.RWMutex
+	tests map[string]ABTest
+}
+
+var abTests = &abTestStore{tests: make(map[string]ABTest)}
+
+func (s *abTestStore) get(persona string) (ABTest, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tests[persona]
+	return t, ok
+}
+
+func (s *abTestStore) set(persona string, t ABTest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tests[persona] = t
+}
+
+func (s *abTestStore) remove(persona string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tests[persona]; !ok {
+		return false
+	}
+	delete(s.tests, persona)
+	return true
+}
+
+// abAssignment records which variant served a plan request.
+type abAssignment struct {
+	requested string
+	variant   string // "a" or "b"; empty when the persona has no test
+}
+
+// assignVariant picks the persona that should serve a request. The draw
+// uses crypto/rand so assignments cannot be predicted from request order.
+func assignVariant(persona string) (string, abAssignment) {
+	t, ok := abTests.get(persona)
+	if !ok {
+		return persona, abAssignment{}
+	}
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return persona, abAssignment{requested: persona, variant: "a"}
+	}
+	if int(binary.BigEndian.Uint32(b[:])%100) < t.SplitPercent {
+		return t.VariantBName, abAssignment{requested: persona, variant: "b"}
+	}
+	return persona, abAssignment{requested: persona, variant: "a"}
+}
+
+func (a abAssignment) apply(resp *PlanResponse) {
+	resp.RequestedPersona = a.requested
+	resp.ABVariant = a.variant
+}
+
+// handlePersonaABTest serves /personas/{name}/ab-test.
+func handlePersonaABTest(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/personas/"), "/ab-test")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		t, ok := abTests.get(name)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no ab test for persona"})
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	case http.MethodPost:
+		var t ABTest
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+			return
+		}
+		if err := validateABTest(name, t); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+		abTests.set(name, t)
+		writeJSON(w, http.StatusOK, t)
+	case http.MethodDelete:
+		if !abTests.remove(name) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no ab test for persona"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func validateABTest(persona string, t ABTest) error {
+	if t.VariantBName == "" || t.VariantBName == persona {
+		return errors.New("variant_b_name must name a different persona")
+	}
+	if t.SplitPercent < 0 || t.SplitPercent > 100 {
+		return errors.New("split_percent must be between 0 and 100")
+	}
+	return nil
+}
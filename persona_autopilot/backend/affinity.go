@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// GoalChannelAffinity scores how well each channel serves a goal, from 0
+// (poor) to 1 (best): goal -> channel -> score.
+type GoalChannelAffinity map[string]map[string]float64
+
+// goalAffinity is seeded by configure from
+// AUTOPILOT_GOAL_CHANNEL_AFFINITY_JSON, e.g.
+// {"awareness":{"youtube":0.9,"email":0.3}}, and may later be swapped by the
+// config file watcher. validateConfig rejects bad values first.
+var goalAffinity atomic.Pointer[GoalChannelAffinity]
+
+func currentGoalAffinity() GoalChannelAffinity {
+	return *goalAffinity.Load()
+}
+
+func parseGoalChannelAffinity(v string) (GoalChannelAffinity, error) {
+	if v == "" {
+		return nil, nil
+	}
+	var a GoalChannelAffinity
+	if err := json.Unmarshal([]byte(v), &a); err != nil {
+		return nil, err
+	}
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a GoalChannelAffinity) validate() error {
+	for goal, chs := range a {
+		for ch, score := range chs {
+			if score < 0 || score > 1 {
+				return fmt.Errorf("affinity for %s/%s must be within [0, 1]", goal, ch)
+			}
+		}
+	}
+	return nil
+}
+
+// rankChannels orders channels by descending affinity for goal so the best
+// fits get the earliest slots. Ties and channels without a score keep their
+// requested order. It returns indices into channels in slot order and the
+// score used for each slot; scores is nil when the goal has no affinity data.
+func (a GoalChannelAffinity) rankChannels(goal string, channels []string) (order []int, scores []float64) {
+	order = make([]int, len(channels))
+	for i := range order {
+		order[i] = i
+	}
+	byChannel, ok := a[goal]
+	if !ok {
+		return order, nil
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return byChannel[channels[order[i]]] > byChannel[channels[order[j]]]
+	})
+	scores = make([]float64, len(order))
+	for i, idx := range order {
+		scores[i] = byChannel[channels[idx]]
+	}
+	return order, scores
+}
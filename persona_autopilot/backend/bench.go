@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const maxBenchChannels = 1000
+
+// benchEndpointEnabled exposes GET /bench/plan for load testing. It is off by
+// default and the route is not registered unless configure finds
+// AUTOPILOT_ENABLE_BENCH_ENDPOINT=true.
+var benchEndpointEnabled bool
+
+// handleBenchPlan synthesizes a plan with N generated channels and a fixed
+// persona, goal and timeframe, reporting timing and allocation in headers.
+func handleBenchPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	n, err := strconv.Atoi(r.URL.Query().Get("channels"))
+	if err != nil || n < 1 || n > maxBenchChannels {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("channels must be an integer between 1 and %d", maxBenchChannels),
+		})
+		return
+	}
+	persona := r.URL.Query().Get("persona")
+	if persona == "" {
+		persona = "benchmark"
+	}
+
+	req := PlanRequest{Persona: persona, Goal: "benchmark", Timeframe: "today"}
+	for i := 0; i < n; i++ {
+		req.Channels = append(req.Channels, fmt.Sprintf("bench-%d", i))
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	items := synthesizePlan(req)
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	w.Header().Set("X-Synthesis-Duration-Ms", strconv.FormatFloat(float64(elapsed.Microseconds())/1000, 'f', 3, 64))
+	w.Header().Set("X-Alloc-Bytes", strconv.FormatUint(after.TotalAlloc-before.TotalAlloc, 10))
+	writeJSON(w, http.StatusOK, PlanResponse{
+		Persona: persona,
+		Items:   items,
+		Stats:   computePlanStats(items),
+	})
+}
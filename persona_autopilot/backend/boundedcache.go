@@ -0,0 +1,119 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cacheEntryOverhead approximates the bookkeeping cost of one entry (list
+// element plus map slot) on top of what the size func reports.
+const cacheEntryOverhead = 96
+
+// BoundedCache is a thread-safe LRU map that evicts least recently used
+// entries once the estimated size of its contents exceeds maxBytes. Sizes
+// come from a caller-supplied estimate, so the budget is approximate.
+type BoundedCache[K comparable, V any] struct {
+	mu        sync.Mutex
+	name      string
+	maxBytes  int64
+	size      func(K, V) int64
+	ll        *list.List
+	items     map[K]*list.Element
+	bytes     int64
+	evictions uint64
+}
+
+type boundedEntry[K comparable, V any] struct {
+	key   K
+	value V
+	size  int64
+}
+
+// CacheStats describes a BoundedCache for /admin/memory-stats.
+type CacheStats struct {
+	Name      string `json:"name"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+	MaxBytes  int64  `json:"max_bytes"`
+	Evictions uint64 `json:"evictions"`
+}
+
+func NewBoundedCache[K comparable, V any](name string, maxBytes int64, size func(K, V) int64) *BoundedCache[K, V] {
+	return &BoundedCache[K, V]{
+		name:     name,
+		maxBytes: maxBytes,
+		size:     size,
+		ll:       list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+// Get returns the value for key and marks it most recently used.
+func (c *BoundedCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*boundedEntry[K, V]).value, true
+}
+
+// Put stores value under key, then evicts from the cold end until the cache
+// fits its budget. An entry larger than the whole budget is not kept.
+func (c *BoundedCache[K, V]) Put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	size := c.size(key, value) + cacheEntryOverhead
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*boundedEntry[K, V])
+		c.bytes += size - e.size
+		e.value, e.size = value, size
+		c.ll.MoveToFront(el)
+	} else {
+		c.items[key] = c.ll.PushFront(&boundedEntry[K, V]{key: key, value: value, size: size})
+		c.bytes += size
+	}
+	for c.bytes > c.maxBytes && c.ll.Len() > 0 {
+		c.removeElement(c.ll.Back())
+		c.evictions++
+	}
+}
+
+func (c *BoundedCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// Clear drops every entry. Cleared entries are not counted as evictions.
+func (c *BoundedCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[K]*list.Element)
+	c.bytes = 0
+}
+
+func (c *BoundedCache[K, V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Name:      c.name,
+		Entries:   c.ll.Len(),
+		Bytes:     c.bytes,
+		MaxBytes:  c.maxBytes,
+		Evictions: c.evictions,
+	}
+}
+
+func (c *BoundedCache[K, V]) removeElement(el *list.Element) {
+	e := c.ll.Remove(el).(*boundedEntry[K, V])
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
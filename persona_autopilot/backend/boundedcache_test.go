@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestCache holds three entries: each costs its value plus the overhead.
+func newTestCache() *BoundedCache[string, int64] {
+	return NewBoundedCache("test", 3*(4+cacheEntryOverhead), func(_ string, v int64) int64 { return v })
+}
+
+func cacheKeys(c *BoundedCache[string, int64]) []string {
+	var keys []string
+	for el := c.ll.Front(); el != nil; el = el.Next() {
+		keys = append(keys, el.Value.(*boundedEntry[string, int64]).key)
+	}
+	return keys
+}
+
+func TestBoundedCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newTestCache()
+	c.Put("a", 4)
+	c.Put("b", 4)
+	c.Put("c", 4)
+	c.Get("a") // a becomes most recent, so b is now the coldest
+	c.Put("d", 4)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b survived, want it evicted as least recently used")
+	}
+	if got, want := cacheKeys(c), []string{"d", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+	if st := c.Stats(); st.Entries != 3 || st.Evictions != 1 || st.Bytes != st.MaxBytes {
+		t.Errorf("stats = %+v, want 3 entries filling the budget after 1 eviction", st)
+	}
+}
+
+func TestBoundedCacheByteBudget(t *testing.T) {
+	c := newTestCache()
+	c.Put("a", 4)
+	c.Put("b", 4)
+	c.Put("c", 4)
+	// Growing a in place pushes out the two colder entries.
+	c.Put("a", 4+2*(4+cacheEntryOverhead))
+
+	if got := cacheKeys(c); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("keys = %v, want only a", got)
+	}
+	if st := c.Stats(); st.Bytes != st.MaxBytes || st.Evictions != 2 {
+		t.Errorf("stats = %+v, want the budget exactly used after 2 evictions", st)
+	}
+}
+
+func TestBoundedCacheOversizedEntry(t *testing.T) {
+	c := newTestCache()
+	c.Put("a", 4)
+	c.Put("huge", 1<<20)
+
+	if _, ok := c.Get("huge"); ok {
+		t.Error("an entry larger than the budget was kept")
+	}
+	// Making room for it emptied the cache before it was dropped too.
+	if st := c.Stats(); st.Entries != 0 || st.Bytes != 0 || st.Evictions != 2 {
+		t.Errorf("stats = %+v, want an empty cache after 2 evictions", st)
+	}
+}
+
+func TestBoundedCacheDeleteAndClear(t *testing.T) {
+	c := newTestCache()
+	c.Put("a", 4)
+	c.Put("b", 4)
+	c.Delete("a")
+	c.Delete("missing")
+	if st := c.Stats(); st.Entries != 1 || st.Bytes != 4+cacheEntryOverhead {
+		t.Errorf("after Delete: stats = %+v, want b alone", st)
+	}
+
+	c.Put("d", 1<<20) // one eviction for b, one for d itself
+	c.Put("e", 4)
+	c.Clear()
+	if st := c.Stats(); st.Entries != 0 || st.Bytes != 0 || st.Evictions != 2 {
+		t.Errorf("after Clear: stats = %+v, want empty with eviction count unchanged", st)
+	}
+	if _, ok := c.Get("e"); ok {
+		t.Error("Get found an entry after Clear")
+	}
+	c.Put("f", 4)
+	if got := cacheKeys(c); !reflect.DeepEqual(got, []string{"f"}) {
+		t.Errorf("keys after reuse = %v, want [f]", got)
+	}
+}
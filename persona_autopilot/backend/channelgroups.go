@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// channelGroupPrefix marks a group alias in PlanRequest.Channels, e.g. "@social".
+const channelGroupPrefix = "@"
+
+type ChannelGroup struct {
+	Name     string   `json:"name" example:"social"`
+	Channels []string `json:"channels" example:"[\"twitter\",\"instagram\",\"@video\"]"`
+}
+
+// channelGroupStore holds group aliases in memory. Members may themselves be
+// group references; cycles are only detected on expansion, since a group may
+// name another that does not exist yet.
+type channelGroupStore struct {
+	mu     sync.RWMutex
+	groups map[string][]string
+}
+
+var channelGroups = &channelGroupStore{groups: make(map[string][]string)}
+
+func (s *channelGroupStore) add(g ChannelGroup) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.groups[g.Name]; ok {
+		return false
+	}
+	s.groups[g.Name] = append([]string(nil), g.Channels...)
+	return true
+}
+
+func (s *channelGroupStore) remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.groups[name]; !ok {
+		return false
+	}
+	delete(s.groups, name)
+	return true
+}
+
+func (s *channelGroupStore) list() []ChannelGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]ChannelGroup, 0, len(s.groups))
+	for name, chs := range s.groups {
+		out = append(out, ChannelGroup{Name: name, Channels: append([]string(nil), chs...)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// errTooManyChannels stops an expansion that would exceed its channel budget.
+var errTooManyChannels = errors.New("too_many_channels")
+
+// expand replaces "@group" entries with their channels, recursively and in
+// order. groups[i] names the alias the caller wrote that produced out[i]
+// (the outermost group for nested ones), or "" for a channel given
+// directly. Unknown groups and circular references are errors.
+//
+// Groups that share members multiply, so a few nested groups can name
+// millions of channels. Expansion stops with errTooManyChannels as soon as
+// out exceeds limit, returning what it has so far.
+func (s *channelGroupStore) expand(channels []string, limit int) (out, groups []string, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var walk func(chs []string, path []string) error
+	walk = func(chs []string, path []string) error {
+		for _, ch := range chs {
+			name, isGroup := strings.CutPrefix(ch, channelGroupPrefix)
+			if !isGroup {
+				out = append(out, ch)
+				if len(path) > 0 {
+					groups = append(groups, path[0])
+				} else {
+					groups = append(groups, "")
+				}
+				if len(out) > limit {
+					return errTooManyChannels
+				}
+				continue
+			}
+			for _, seen := range path {
+				if seen == name {
+					return fmt.Errorf("circular channel group reference: %s", strings.Join(append(path, name), " -> "))
+				}
+			}
+			members, ok := s.groups[name]
+			if !ok {
+				return fmt.Errorf("unknown channel group %q", name)
+			}
+			if err := walk(members, append(path, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(channels, nil); err != nil {
+		return out, groups, err
+	}
+	return out, groups, nil
+}
+
+func handleChannelGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, channelGroups.list())
+	case http.MethodPost:
+		var g ChannelGroup
+		if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+			return
+		}
+		if err := validateChannelGroup(g); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+		// A group can never usefully list more channels than one plan accepts.
+		if len(g.Channels) > maxChannelsPerPlan {
+			writeLimitError(w, "too_many_channels", maxChannelsPerPlan, len(g.Channels))
+			return
+		}
+		if !channelGroups.add(g) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "channel group already exists"})
+			return
+		}
+		writeJSON(w, http.StatusCreated, g)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func handleChannelGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/channel-groups/")
+	if !channelGroups.remove(name) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "channel group not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateChannelGroup keeps group names disjoint from channel names: a
+// name carries no "@" itself and is only ever referenced with the prefix.
+func validateChannelGroup(g ChannelGroup) error {
+	if g.Name == "" || strings.ContainsAny(g.Name, "/@") {
+		return errors.New("group name must be non-empty and must not contain '/' or '@'")
+	}
+	if len(g.Channels) == 0 {
+		return fmt.Errorf("group %q needs at least one channel", g.Name)
+	}
+	for _, ch := range g.Channels {
+		if ch == "" || ch == channelGroupPrefix {
+			return errors.New("channel names must be non-empty")
+		}
+	}
+	return nil
+}
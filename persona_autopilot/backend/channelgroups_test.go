@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestChannelGroupExpand(t *testing.T) {
+	s := &channelGroupStore{groups: map[string][]string{
+		"social": {"twitter", "@video"},
+		"video":  {"youtube", "tiktok"},
+		"loop-a": {"@loop-b"},
+		"loop-b": {"@loop-a"},
+	}}
+
+	out, groups, err := s.expand([]string{"email", "@social"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"email", "twitter", "youtube", "tiktok"}; !reflect.DeepEqual(out, want) {
+		t.Errorf("channels = %v, want %v", out, want)
+	}
+	if want := []string{"", "social", "social", "social"}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("groups = %v, want %v", groups, want)
+	}
+
+	if _, _, err := s.expand([]string{"@loop-a"}, 10); err == nil {
+		t.Error("circular reference expanded without error")
+	}
+	if _, _, err := s.expand([]string{"@missing"}, 10); err == nil {
+		t.Error("unknown group expanded without error")
+	}
+	if out, _, err := s.expand([]string{"@social", "sms"}, 3); !errors.Is(err, errTooManyChannels) || len(out) != 4 {
+		t.Errorf("expand over budget = %v, %v; want errTooManyChannels after 4 channels", out, err)
+	}
+}
+
+// Groups that each reference the previous one twice double at every level;
+// expansion must stop at the budget instead of building 2^n channels.
+func TestChannelGroupExpandStopsAtBudget(t *testing.T) {
+	s := &channelGroupStore{groups: map[string][]string{"g0": {"a", "b"}}}
+	for i := 1; i < 40; i++ {
+		prev := fmt.Sprintf("@g%d", i-1)
+		s.groups[fmt.Sprintf("g%d", i)] = []string{prev, prev}
+	}
+
+	out, _, err := s.expand([]string{"@g39"}, 100)
+	if !errors.Is(err, errTooManyChannels) || len(out) != 101 {
+		t.Fatalf("expand = %d channels, %v; want errTooManyChannels after 101", len(out), err)
+	}
+}
+
+func TestChannelGroupMemberLimit(t *testing.T) {
+	s := NewTestServer(t)
+	members := make([]string, maxChannelsPerPlan+1)
+	for i := range members {
+		members[i] = fmt.Sprintf("ch-%d", i)
+	}
+	var resp map[string]any
+	s.DoJSON(t, http.MethodPost, "/channel-groups", ChannelGroup{Name: "too-big", Channels: members},
+		http.StatusUnprocessableEntity, &resp)
+	if resp["error"] != "too_many_channels" {
+		t.Errorf("response = %v, want too_many_channels", resp)
+	}
+}
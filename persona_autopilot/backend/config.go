@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// ConfigError describes an environment variable that cannot be used.
+type ConfigError struct {
+	Var      string
+	Value    string
+	Expected string
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s=%q: expected %s", e.Var, e.Value, e.Expected)
+}
+
+type envSpec struct {
+	name     string
+	expected string
+	valid    func(string) bool
+}
+
+// envSpecs lists every variable the backend reads that has a format to
+// check. Free-form values (AUTOPILOT_CSP_HEADER, AUTOPILOT_GOOGLE_API_KEY)
+// are not listed. Keep this in sync when adding settings. Globals derived
+// from these are set by configure, which main runs after validation.
+var envSpecs = []envSpec{
+	{"AUTOPILOT_BACKEND_ADDR", "a listen address such as :8080 or 127.0.0.1:8080", validAddr},
+	{"AUTOPILOT_READ_TIMEOUT_MS", "an integer from 1 to 3600000 (milliseconds)", validIntRange(1, 3600000)},
+	{"AUTOPILOT_WRITE_TIMEOUT_MS", "an integer from 1 to 3600000 (milliseconds)", validIntRange(1, 3600000)},
+	{"AUTOPILOT_IDLE_TIMEOUT_MS", "an integer from 1 to 3600000 (milliseconds)", validIntRange(1, 3600000)},
+	{"AUTOPILOT_PERSONA_PLAN_RPM", "an integer from 1 to 1000000", validIntRange(1, 1000000)},
+	{"AUTOPILOT_MAX_CHANNELS_PER_PLAN", "an integer from 1 to 10000", validIntRange(1, 10000)},
+	{"AUTOPILOT_MAX_PLAN_ITEMS_STORED", "an integer from 1 to 1000000", validIntRange(1, 1000000)},
+	{"AUTOPILOT_HEALTH_HISTORY_SIZE", "an integer from 1 to 100000", validIntRange(1, 100000)},
+	{"AUTOPILOT_PLAN_CACHE_MAX_BYTES", "an integer from 1 to 1073741824 (bytes)", validIntRange(1, 1<<30)},
+	{"AUTOPILOT_RATE_LIMIT_CACHE_MAX_BYTES", "an integer from 1 to 1073741824 (bytes)", validIntRange(1, 1<<30)},
+	{"AUTOPILOT_MAX_DECOMPRESSED_BODY_BYTES", "an integer from 1 to 1073741824 (bytes)", validIntRange(1, 1<<30)},
+	{"AUTOPILOT_ENABLE_ALLOC_DEBUG", "true or false", validBool},
+	{"AUTOPILOT_REJECT_PII", "true or false", validBool},
+	{"AUTOPILOT_ENABLE_BENCH_ENDPOINT", "true or false", validBool},
+	{"AUTOPILOT_ENABLE_LOAD_TEST", "true or false", validBool},
+	{"AUTOPILOT_GOAL_CHANNEL_AFFINITY_JSON", `a JSON object of goal -> channel -> score in [0, 1]`, validAffinityJSON},
+	{"AUTOPILOT_CONFIG_FILE", `a readable JSON file such as {"goal_channel_affinity":{...}}`, validConfigFile},
+	{"AUTOPILOT_CONFIG_RELOAD_INTERVAL_SECONDS", "an integer from 1 to 86400", validIntRange(1, 86400)},
+}
+
+// validateConfig checks every set variable in envSpecs. Unset variables use
+// their defaults and are not errors.
+func validateConfig() []ConfigError {
+	var errs []ConfigError
+	for _, spec := range envSpecs {
+		v, ok := os.LookupEnv(spec.name)
+		if !ok || v == "" {
+			continue
+		}
+		if !spec.valid(v) {
+			errs = append(errs, ConfigError{Var: spec.name, Value: v, Expected: spec.expected})
+		}
+	}
+	return errs
+}
+
+// validIntRange accepts decimal integers within [lo, hi]. Sizes and budgets
+// are bounded so a typo fails validation instead of exhausting memory.
+func validIntRange(lo, hi int64) func(string) bool {
+	return func(v string) bool {
+		n, err := strconv.ParseInt(v, 10, 64)
+		return err == nil && n >= lo && n <= hi
+	}
+}
+
+func validBool(v string) bool {
+	return v == "true" || v == "false"
+}
+
+func validAffinityJSON(v string) bool {
+	_, err := parseGoalChannelAffinity(v)
+	return err == nil
+}
+
+func validConfigFile(path string) bool {
+	_, _, err := readConfigFile(path)
+	return err == nil
+}
+
+func validAddr(v string) bool {
+	_, port, err := net.SplitHostPort(v)
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 0 && n <= 65535
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		ok    bool
+	}{
+		{"in range", "AUTOPILOT_HEALTH_HISTORY_SIZE", "500", true},
+		{"above the bound", "AUTOPILOT_HEALTH_HISTORY_SIZE", "100000000000", false},
+		{"zero", "AUTOPILOT_PLAN_CACHE_MAX_BYTES", "0", false},
+		{"cache budget too large", "AUTOPILOT_PLAN_CACHE_MAX_BYTES", "1099511627776", false},
+		{"not a number", "AUTOPILOT_MAX_CHANNELS_PER_PLAN", "lots", false},
+		{"bool", "AUTOPILOT_REJECT_PII", "yes", false},
+		{"address", "AUTOPILOT_BACKEND_ADDR", "127.0.0.1:8080", true},
+		{"bad affinity", "AUTOPILOT_GOAL_CHANNEL_AFFINITY_JSON", `{"awareness":{"email":2}}`, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(tc.key, tc.value)
+			errs := validateConfig()
+			if tc.ok && len(errs) > 0 {
+				t.Errorf("%s=%s: unexpected errors %v", tc.key, tc.value, errs)
+			}
+			if !tc.ok && (len(errs) != 1 || errs[0].Var != tc.key) {
+				t.Errorf("%s=%s: errors = %v, want one for %s", tc.key, tc.value, errs, tc.key)
+			}
+		})
+	}
+}
+
+func TestConfigureReadsEnvAfterInit(t *testing.T) {
+	// Registered first so it runs last, once t.Setenv has restored the env.
+	t.Cleanup(configure)
+	t.Setenv("AUTOPILOT_REJECT_PII", "true")
+	t.Setenv("AUTOPILOT_ENABLE_BENCH_ENDPOINT", "true")
+	t.Setenv("AUTOPILOT_HEALTH_HISTORY_SIZE", "7")
+	t.Setenv("AUTOPILOT_GOAL_CHANNEL_AFFINITY_JSON", `{"awareness":{"email":0.5}}`)
+	configure()
+
+	if !rejectPII || !benchEndpointEnabled || loadTestEnabled || allocDebugEnabled {
+		t.Errorf("flags: reject_pii=%v bench=%v load_test=%v alloc_debug=%v",
+			rejectPII, benchEndpointEnabled, loadTestEnabled, allocDebugEnabled)
+	}
+	if len(healthLog.buf) != 7 {
+		t.Errorf("health history size = %d, want 7", len(healthLog.buf))
+	}
+	if got := currentGoalAffinity()["awareness"]["email"]; got != 0.5 {
+		t.Errorf("affinity awareness/email = %v, want 0.5", got)
+	}
+}
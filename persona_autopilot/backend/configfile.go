@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"time"
+)
+
+// fileConfig is the hot-reloadable subset of configuration read from
+// AUTOPILOT_CONFIG_FILE. Fields left out of the file keep their current
+// values; unknown fields are ignored.
+type fileConfig struct {
+	GoalChannelAffinity *GoalChannelAffinity `json:"goal_channel_affinity"`
+}
+
+// readConfigFile loads and validates path. It also returns the file's
+// modification time as of just before the read, so a watcher started from
+// it cannot miss an edit made after the read. On any error the config is
+// the zero value and must not be applied.
+func readConfigFile(path string) (fileConfig, time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileConfig{}, time.Time{}, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fileConfig{}, time.Time{}, err
+	}
+	var cfg fileConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return fileConfig{}, time.Time{}, err
+	}
+	if cfg.GoalChannelAffinity != nil {
+		if err := cfg.GoalChannelAffinity.validate(); err != nil {
+			return fileConfig{}, time.Time{}, fmt.Errorf("goal_channel_affinity: %w", err)
+		}
+	}
+	return cfg, fi.ModTime(), nil
+}
+
+// applyConfigFile installs cfg and returns the names of fields that changed.
+// Any change clears the plan cache, since every field feeds synthesis and
+// cached plans would otherwise keep the old ordering until they expire.
+func applyConfigFile(cfg fileConfig) []string {
+	var changed []string
+	if cfg.GoalChannelAffinity != nil && !reflect.DeepEqual(*cfg.GoalChannelAffinity, currentGoalAffinity()) {
+		goalAffinity.Store(cfg.GoalChannelAffinity)
+		changed = append(changed, "goal_channel_affinity")
+	}
+	if len(changed) > 0 {
+		planResponses.clear()
+	}
+	return changed
+}
+
+// watchConfigFile reloads path whenever its modification time differs from
+// lastMod, the time of the config already applied. An unreadable or invalid
+// file is logged and the last good config is kept.
+func watchConfigFile(path string, lastMod time.Time, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		fi, err := os.Stat(path)
+		if err != nil {
+			log.Printf("ERROR config file %s: %v", path, err)
+			continue
+		}
+		if fi.ModTime().Equal(lastMod) {
+			continue
+		}
+		lastMod = fi.ModTime()
+
+		cfg, _, err := readConfigFile(path)
+		if err != nil {
+			log.Printf("ERROR reloading config file %s, keeping previous config: %v", path, err)
+			continue
+		}
+		if changed := applyConfigFile(cfg); len(changed) > 0 {
+			log.Printf("config reloaded from %s: changed %v", path, changed)
+		}
+	}
+}
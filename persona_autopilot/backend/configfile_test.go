@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApplyConfigFileClearsPlanCache(t *testing.T) {
+	old := goalAffinity.Load()
+	defer goalAffinity.Store(old)
+
+	s := NewTestServer(t)
+	req := PlanRequest{Persona: "reload-test", Channels: []string{"email", "youtube"}, Goal: "awareness"}
+	plan := func() (PlanResponse, string) {
+		resp, body := s.Do(t, http.MethodPost, "/plan", req, nil)
+		var p PlanResponse
+		if err := json.Unmarshal(body, &p); err != nil || resp.StatusCode != http.StatusOK || len(p.Items) != 2 {
+			t.Fatalf("status %d, body %s", resp.StatusCode, body)
+		}
+		return p, resp.Header.Get("X-Cache")
+	}
+
+	if first, _ := plan(); first.Items[0].Channel != "email" {
+		t.Fatalf("before reload, first channel = %s, want email", first.Items[0].Channel)
+	}
+
+	a := GoalChannelAffinity{"awareness": {"youtube": 0.9, "email": 0.3}}
+	if changed := applyConfigFile(fileConfig{GoalChannelAffinity: &a}); len(changed) != 1 {
+		t.Fatalf("changed = %v, want goal_channel_affinity", changed)
+	}
+	after, cache := plan()
+	if cache != "MISS" {
+		t.Errorf("X-Cache after reload = %q, want MISS", cache)
+	}
+	if after.Items[0].Channel != "youtube" || after.Items[0].AffinityScore != 0.9 {
+		t.Errorf("after reload, first item = %+v, want youtube scored 0.9", after.Items[0])
+	}
+
+	// Re-applying the same config changes nothing and keeps the cache.
+	if changed := applyConfigFile(fileConfig{GoalChannelAffinity: &a}); len(changed) != 0 {
+		t.Errorf("reapplying changed %v", changed)
+	}
+	if _, cache := plan(); cache != "HIT" {
+		t.Errorf("X-Cache after no-op reload = %q, want HIT", cache)
+	}
+}
+
+func TestReadConfigFileRejectsInvalidScores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"goal_channel_affinity":{"awareness":{"email":5}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, modTime, err := readConfigFile(path)
+	if err == nil || cfg.GoalChannelAffinity != nil || !modTime.IsZero() {
+		t.Errorf("readConfigFile = %+v, %v, %v; want a zero config and an error", cfg, modTime, err)
+	}
+}
+
+func TestWatchConfigFileReloadsEditsAfterBaseline(t *testing.T) {
+	old := goalAffinity.Load()
+	defer goalAffinity.Store(old)
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"goal_channel_affinity":{"awareness":{"email":0.2}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, modTime, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyConfigFile(cfg)
+
+	// An edit between the startup read and the watcher's first poll must
+	// still be picked up, since the watcher starts from modTime.
+	if err := os.WriteFile(path, []byte(`{"goal_channel_affinity":{"awareness":{"email":0.7}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime.Add(time.Second), modTime.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	go watchConfigFile(path, modTime, 10*time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for currentGoalAffinity()["awareness"]["email"] != 0.7 {
+		if time.Now().After(deadline) {
+			t.Fatalf("affinity = %v, want the edited 0.7", currentGoalAffinity())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
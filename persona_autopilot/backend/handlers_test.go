@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthHandlers(t *testing.T) {
+	s := NewTestServer(t)
+	if h := s.CheckHealth(t); h.Status != "ok" || h.Checks["server"] != "ok" {
+		t.Errorf("health = %+v, want ok", h)
+	}
+
+	var history []HealthRecord
+	s.DoJSON(t, http.MethodGet, "/health/history", nil, http.StatusOK, &history)
+	if len(history) == 0 || history[len(history)-1].Status != "ok" {
+		t.Errorf("history = %+v, want the check above as the latest record", history)
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"history rejects POST", http.MethodPost, "/health/history", nil, http.StatusMethodNotAllowed},
+	})
+}
+
+func TestPlanHandler(t *testing.T) {
+	s := NewTestServer(t)
+	resp := s.PlanRequest(t, PlanRequest{
+		Persona: "handler-plan", Channels: []string{"twitter", "email"}, Goal: "awareness", Timeframe: "today",
+	})
+	if resp.Persona != "handler-plan" || len(resp.Items) != 2 || resp.Stats.ItemCount != 2 {
+		t.Errorf("plan = %+v, want two items for handler-plan", resp)
+	}
+
+	tooMany := make([]string, maxChannelsPerPlan+1)
+	for i := range tooMany {
+		tooMany[i] = "ch"
+	}
+	runHandlerCases(t, s, []handlerCase{
+		{"wrong method", http.MethodGet, "/plan", nil, http.StatusMethodNotAllowed},
+		{"invalid JSON", http.MethodPost, "/plan", "{", http.StatusBadRequest},
+		{"missing persona", http.MethodPost, "/plan",
+			PlanRequest{Channels: []string{"twitter"}}, http.StatusUnprocessableEntity},
+		{"no channels", http.MethodPost, "/plan",
+			PlanRequest{Persona: "handler-plan"}, http.StatusUnprocessableEntity},
+		{"empty channel", http.MethodPost, "/plan",
+			PlanRequest{Persona: "handler-plan", Channels: []string{""}}, http.StatusUnprocessableEntity},
+		{"bad timezone", http.MethodPost, "/plan",
+			PlanRequest{Persona: "handler-plan", Channels: []string{"twitter"}, Timezone: "Mars/Olympus"},
+			http.StatusUnprocessableEntity},
+		{"unknown group", http.MethodPost, "/plan",
+			PlanRequest{Persona: "handler-plan", Channels: []string{"@no-such-group"}}, http.StatusUnprocessableEntity},
+		{"too many channels", http.MethodPost, "/plan",
+			PlanRequest{Persona: "handler-plan", Channels: tooMany}, http.StatusUnprocessableEntity},
+		{"validate only", http.MethodPost, "/plan?validate_only=true",
+			PlanRequest{Persona: "handler-plan-dry", Channels: []string{"email"}}, http.StatusOK},
+	})
+}
+
+func TestPlanStreamHandler(t *testing.T) {
+	s := NewTestServer(t)
+	resp, data := s.Do(t, http.MethodGet, "/plan/stream", PlanRequest{
+		Persona: "handler-stream", Channels: []string{"twitter", "email", "sms"}, Goal: "awareness", Timeframe: "today",
+	}, nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("status %d, content type %q; body %s", resp.StatusCode, resp.Header.Get("Content-Type"), data)
+	}
+
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 3 items and a done line:\n%s", len(lines), data)
+	}
+	var item PlanStreamItem
+	if err := json.Unmarshal([]byte(lines[2]), &item); err != nil || item.Progress != (StreamProgress{Current: 3, Total: 3}) {
+		t.Errorf("third line = %s, want progress 3/3", lines[2])
+	}
+	var done PlanStreamDone
+	if err := json.Unmarshal([]byte(lines[3]), &done); err != nil || !done.Done || done.Stats.ItemCount != 3 {
+		t.Errorf("last line = %s, want done with 3 items", lines[3])
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"wrong method", http.MethodPost, "/plan/stream", nil, http.StatusMethodNotAllowed},
+		{"invalid JSON", http.MethodGet, "/plan/stream", "{", http.StatusBadRequest},
+		{"no channels", http.MethodGet, "/plan/stream", PlanRequest{Persona: "handler-stream"}, http.StatusUnprocessableEntity},
+	})
+}
+
+func TestPostHandler(t *testing.T) {
+	s := NewTestServer(t)
+	resp := s.PostContent(t, PostRequest{Persona: "brand-x", Channel: "twitter", Content: "hello"})
+	if resp.Status != "queued" || resp.Channel != "twitter" || !strings.HasPrefix(resp.ID, "twitter-") {
+		t.Errorf("post = %+v, want a queued twitter post", resp)
+	}
+
+	pii := PostRequest{Persona: "brand-x", Channel: "email", Content: "mail me at someone@example.com"}
+	runHandlerCases(t, s, []handlerCase{
+		{"wrong method", http.MethodGet, "/post", nil, http.StatusMethodNotAllowed},
+		{"invalid JSON", http.MethodPost, "/post", "{", http.StatusBadRequest},
+		{"PII is only logged by default", http.MethodPost, "/post", pii, http.StatusAccepted},
+	})
+
+	defer func(old bool) { rejectPII = old }(rejectPII)
+	rejectPII = true
+	runHandlerCases(t, s, []handlerCase{
+		{"PII rejected when configured", http.MethodPost, "/post", pii, http.StatusUnprocessableEntity},
+	})
+}
+
+func TestValidatePostBatchHandler(t *testing.T) {
+	s := NewTestServer(t)
+	var resp BatchValidationResponse
+	s.DoJSON(t, http.MethodPost, "/posts/validate-batch", []PostRequest{
+		{Channel: "twitter", Content: "hello"},
+		{Channel: "email", Content: "call +15551234567"},
+	}, http.StatusOK, &resp)
+	if resp.Valid != 2 || resp.Invalid != 0 || len(resp.Items) != 1 || resp.Items[0].Index != 1 {
+		t.Errorf("batch = %+v, want 2 valid with a warning on item 1", resp)
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"wrong method", http.MethodGet, "/posts/validate-batch", nil, http.StatusMethodNotAllowed},
+		{"not an array", http.MethodPost, "/posts/validate-batch", PostRequest{}, http.StatusBadRequest},
+	})
+}
+
+func TestPersonaABTestHandler(t *testing.T) {
+	s := NewTestServer(t)
+	const path = "/personas/handler-ab/ab-test"
+	runHandlerCases(t, s, []handlerCase{
+		{"get before create", http.MethodGet, path, nil, http.StatusNotFound},
+		{"bad path", http.MethodGet, "/personas/handler-ab", nil, http.StatusNotFound},
+		{"invalid JSON", http.MethodPost, path, "{", http.StatusBadRequest},
+		{"same variant", http.MethodPost, path, ABTest{VariantBName: "handler-ab", SplitPercent: 50}, http.StatusUnprocessableEntity},
+		{"split out of range", http.MethodPost, path, ABTest{VariantBName: "handler-ab-b", SplitPercent: 101}, http.StatusUnprocessableEntity},
+		{"create", http.MethodPost, path, ABTest{VariantBName: "handler-ab-b", SplitPercent: 100}, http.StatusOK},
+		{"get", http.MethodGet, path, nil, http.StatusOK},
+		{"wrong method", http.MethodPut, path, nil, http.StatusMethodNotAllowed},
+	})
+
+	// A 100% split always serves the variant.
+	plan := s.PlanRequest(t, PlanRequest{Persona: "handler-ab", Channels: []string{"email"}})
+	if plan.Persona != "handler-ab-b" || plan.RequestedPersona != "handler-ab" || plan.ABVariant != "b" {
+		t.Errorf("plan = %+v, want variant b", plan)
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"delete", http.MethodDelete, path, nil, http.StatusNoContent},
+		{"delete again", http.MethodDelete, path, nil, http.StatusNotFound},
+	})
+}
+
+func TestSheetsHandlers(t *testing.T) {
+	t.Setenv("AUTOPILOT_GOOGLE_API_KEY", "")
+	s := NewTestServer(t)
+	runHandlerCases(t, s, []handlerCase{
+		{"import wrong method", http.MethodGet, "/integrations/google-sheets/import", nil, http.StatusMethodNotAllowed},
+		{"import invalid JSON", http.MethodPost, "/integrations/google-sheets/import", "{", http.StatusBadRequest},
+		{"import missing fields", http.MethodPost, "/integrations/google-sheets/import",
+			SheetsImportRequest{SpreadsheetID: "abc"}, http.StatusBadRequest},
+		{"import not configured", http.MethodPost, "/integrations/google-sheets/import",
+			SheetsImportRequest{SpreadsheetID: "abc", SheetName: "Plan", Persona: "brand-x"}, http.StatusServiceUnavailable},
+		{"columns wrong method", http.MethodPost, "/integrations/google-sheets/columns/abc", nil, http.StatusMethodNotAllowed},
+		{"columns missing id", http.MethodGet, "/integrations/google-sheets/columns/", nil, http.StatusNotFound},
+		{"columns not configured", http.MethodGet, "/integrations/google-sheets/columns/abc", nil, http.StatusServiceUnavailable},
+	})
+}
+
+func TestChannelGroupHandlers(t *testing.T) {
+	s := NewTestServer(t)
+	runHandlerCases(t, s, []handlerCase{
+		{"create", http.MethodPost, "/channel-groups",
+			ChannelGroup{Name: "handler-social", Channels: []string{"twitter", "instagram"}}, http.StatusCreated},
+		{"duplicate", http.MethodPost, "/channel-groups",
+			ChannelGroup{Name: "handler-social", Channels: []string{"email"}}, http.StatusConflict},
+		{"invalid JSON", http.MethodPost, "/channel-groups", "{", http.StatusBadRequest},
+		{"bad name", http.MethodPost, "/channel-groups",
+			ChannelGroup{Name: "@bad", Channels: []string{"email"}}, http.StatusUnprocessableEntity},
+		{"no channels", http.MethodPost, "/channel-groups", ChannelGroup{Name: "handler-empty"}, http.StatusUnprocessableEntity},
+		{"list", http.MethodGet, "/channel-groups", nil, http.StatusOK},
+		{"wrong method", http.MethodPut, "/channel-groups", nil, http.StatusMethodNotAllowed},
+	})
+
+	plan := s.PlanRequest(t, PlanRequest{Persona: "handler-groups", Channels: []string{"email", "@handler-social"}})
+	if len(plan.Items) != 3 || plan.Items[0].ExpandedFromGroup != nil ||
+		plan.Items[2].ExpandedFromGroup == nil || *plan.Items[2].ExpandedFromGroup != "handler-social" {
+		t.Errorf("plan items = %+v, want email then the expanded group", plan.Items)
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"delete", http.MethodDelete, "/channel-groups/handler-social", nil, http.StatusNoContent},
+		{"delete again", http.MethodDelete, "/channel-groups/handler-social", nil, http.StatusNotFound},
+		{"delete wrong method", http.MethodGet, "/channel-groups/handler-social", nil, http.StatusMethodNotAllowed},
+	})
+}
+
+func TestMemoryStatsHandler(t *testing.T) {
+	s := NewTestServer(t)
+	var stats []CacheStats
+	s.DoJSON(t, http.MethodGet, "/admin/memory-stats", nil, http.StatusOK, &stats)
+	if len(stats) != 2 || stats[0].Name != "plan_responses" || stats[1].Name != "persona_plan_rate_limits" {
+		t.Errorf("stats = %+v, want the plan cache and rate limiter", stats)
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"wrong method", http.MethodPost, "/admin/memory-stats", nil, http.StatusMethodNotAllowed},
+	})
+}
+
+func TestOpenAPIHandlers(t *testing.T) {
+	s := NewTestServer(t)
+	var spec map[string]any
+	s.DoJSON(t, http.MethodGet, "/openapi.json", nil, http.StatusOK, &spec)
+	paths, _ := spec["paths"].(map[string]any)
+	for _, rt := range routes() {
+		path := rt.pattern
+		if rt.path != "" {
+			path = rt.path
+		}
+		if op, _ := paths[path].(map[string]any); op[strings.ToLower(rt.method)] == nil {
+			t.Errorf("spec has no %s %s", rt.method, path)
+		}
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"spec wrong method", http.MethodPost, "/openapi.json", nil, http.StatusMethodNotAllowed},
+		{"docs wrong method", http.MethodPost, "/docs", nil, http.StatusMethodNotAllowed},
+	})
+}
+
+func TestBenchPlanHandler(t *testing.T) {
+	runHandlerCases(t, NewTestServer(t), []handlerCase{
+		{"disabled", http.MethodGet, "/bench/plan?channels=3", nil, http.StatusNotFound},
+	})
+
+	defer func(old bool) { benchEndpointEnabled = old }(benchEndpointEnabled)
+	benchEndpointEnabled = true
+	s := NewTestServer(t)
+	var resp PlanResponse
+	s.DoJSON(t, http.MethodGet, "/bench/plan?channels=3", nil, http.StatusOK, &resp)
+	if len(resp.Items) != 3 || resp.Persona != "benchmark" {
+		t.Errorf("bench plan = %+v, want 3 items for benchmark", resp)
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"wrong method", http.MethodPost, "/bench/plan?channels=3", nil, http.StatusMethodNotAllowed},
+		{"missing channels", http.MethodGet, "/bench/plan", nil, http.StatusBadRequest},
+		{"too many channels", http.MethodGet, "/bench/plan?channels=1001", nil, http.StatusBadRequest},
+	})
+}
+
+func TestLoadTestHandler(t *testing.T) {
+	runHandlerCases(t, NewTestServer(t), []handlerCase{
+		{"disabled", http.MethodPost, "/admin/load-test", LoadTestRequest{RPS: 1, DurationSeconds: 1}, http.StatusNotFound},
+	})
+
+	defer func(old bool) { loadTestEnabled = old }(loadTestEnabled)
+	loadTestEnabled = true
+	s := NewTestServer(t)
+	var res LoadTestResult
+	s.DoJSON(t, http.MethodPost, "/admin/load-test", LoadTestRequest{RPS: 20, DurationSeconds: 1}, http.StatusOK, &res)
+	if res.Submitted == 0 || res.Accepted != res.Submitted || res.Rejected != 0 {
+		t.Errorf("load test = %+v, want every submitted post accepted", res)
+	}
+
+	runHandlerCases(t, s, []handlerCase{
+		{"wrong method", http.MethodGet, "/admin/load-test", nil, http.StatusMethodNotAllowed},
+		{"invalid JSON", http.MethodPost, "/admin/load-test", "{", http.StatusBadRequest},
+		{"rps out of range", http.MethodPost, "/admin/load-test",
+			LoadTestRequest{RPS: maxLoadTestRPS + 1, DurationSeconds: 1}, http.StatusBadRequest},
+		{"duration out of range", http.MethodPost, "/admin/load-test",
+			LoadTestRequest{RPS: 1, DurationSeconds: 0}, http.StatusBadRequest},
+	})
+}
+
+func TestLoadTestSingleRun(t *testing.T) {
+	defer func(old bool) { loadTestEnabled = old }(loadTestEnabled)
+	loadTestEnabled = true
+	s := NewTestServer(t)
+
+	loadTestRunning.Store(true)
+	defer loadTestRunning.Store(false)
+	runHandlerCases(t, s, []handlerCase{
+		{"run in progress", http.MethodPost, "/admin/load-test", LoadTestRequest{RPS: 1, DurationSeconds: 1}, http.StatusConflict},
+	})
+}
+
+func TestRunLoadTestStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	res := runLoadTest(ctx, LoadTestRequest{RPS: maxLoadTestRPS, Persona: "bench", Channel: "stub"}, time.Minute)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled run took %s", elapsed)
+	}
+	if res.Accepted != res.Submitted {
+		t.Errorf("result = %+v, want every submitted post accepted", res)
+	}
+}
+
+func TestValidateOnlySkipsRateLimit(t *testing.T) {
+	s := NewTestServer(t)
+	// Distinct channels keep every request out of the plan cache.
+	for i := 0; i < 2*envInt("AUTOPILOT_PERSONA_PLAN_RPM", 10); i++ {
+		s.DoJSON(t, http.MethodPost, "/plan?validate_only=true", PlanRequest{
+			Persona: "handler-dry-limit", Channels: []string{fmt.Sprintf("ch-%d", i)},
+		}, http.StatusOK, nil)
+	}
+	// Real plan creation is still allowed afterwards.
+	s.PlanRequest(t, PlanRequest{Persona: "handler-dry-limit", Channels: []string{"email"}})
+}
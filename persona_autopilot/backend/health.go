@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+type HealthResponse struct {
+	Status        string            `json:"status" example:"ok"`
+	Checks        map[string]string `json:"checks"`
+	DegradedSince string            `json:"degraded_since,omitempty"` // start of the current non-ok streak
+}
+
+type HealthRecord struct {
+	Time   string            `json:"time"`
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// healthLog is sized by AUTOPILOT_HEALTH_HISTORY_SIZE in configure.
+var healthLog *healthHistory
+
+// runHealthChecks returns each check's outcome; anything other than "ok"
+// degrades the overall status. Dependency checks belong here. It is a
+// variable so tests can simulate a degraded dependency.
+var runHealthChecks = func() map[string]string {
+	return map[string]string{"server": "ok"}
+}
+
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	checks := runHealthChecks()
+	status := "ok"
+	for _, v := range checks {
+		if v != "ok" {
+			status = "degraded"
+		}
+	}
+
+	healthLog.record(HealthRecord{
+		Time:   time.Now().UTC().Format(time.RFC3339),
+		Status: status,
+		Checks: checks,
+	})
+	writeJSON(w, http.StatusOK, HealthResponse{
+		Status:        status,
+		Checks:        checks,
+		DegradedSince: healthLog.degradedSince(),
+	})
+}
+
+func handleHealthHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, healthLog.snapshot())
+}
+
+// healthHistory is a fixed-size ring buffer of health check outcomes.
+type healthHistory struct {
+	mu   sync.Mutex
+	buf  []HealthRecord
+	next int
+	full bool
+}
+
+func newHealthHistory(size int) *healthHistory {
+	return &healthHistory{buf: make([]HealthRecord, size)}
+}
+
+func (h *healthHistory) record(rec HealthRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buf[h.next] = rec
+	h.next = (h.next + 1) % len(h.buf)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// snapshot returns the recorded outcomes oldest first.
+func (h *healthHistory) snapshot() []HealthRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]HealthRecord{}, h.buf[:h.next]...)
+	}
+	return append(append([]HealthRecord{}, h.buf[h.next:]...), h.buf[:h.next]...)
+}
+
+// degradedSince returns the time of the first record in the trailing run of
+// non-ok outcomes, or "" when the latest outcome is ok. A streak older than
+// the buffer reports its oldest retained record.
+func (h *healthHistory) degradedSince() string {
+	recs := h.snapshot()
+	since := ""
+	for i := len(recs) - 1; i >= 0 && recs[i].Status != "ok"; i-- {
+		since = recs[i].Time
+	}
+	return since
+}
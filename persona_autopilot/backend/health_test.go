@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func recordStatuses(h *healthHistory, statuses ...string) {
+	for i, st := range statuses {
+		h.record(HealthRecord{Time: string(rune('a' + i)), Status: st})
+	}
+}
+
+func times(recs []HealthRecord) []string {
+	out := []string{}
+	for _, r := range recs {
+		out = append(out, r.Time)
+	}
+	return out
+}
+
+func TestHealthHistoryWraparound(t *testing.T) {
+	tests := []struct {
+		records int
+		want    []string
+	}{
+		{0, []string{}},
+		{2, []string{"a", "b"}},
+		{3, []string{"a", "b", "c"}},
+		{5, []string{"c", "d", "e"}},
+		{7, []string{"e", "f", "g"}},
+	}
+	for _, tc := range tests {
+		h := newHealthHistory(3)
+		for i := 0; i < tc.records; i++ {
+			h.record(HealthRecord{Time: string(rune('a' + i)), Status: "ok"})
+		}
+		if got := times(h.snapshot()); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("after %d records: snapshot = %v, want %v", tc.records, got, tc.want)
+		}
+	}
+}
+
+func TestHealthHistoryDegradedSince(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		statuses []string
+		want     string
+	}{
+		{"empty", 5, nil, ""},
+		{"all ok", 5, []string{"ok", "ok"}, ""},
+		{"current streak", 5, []string{"ok", "degraded", "degraded"}, "b"},
+		{"recovered", 5, []string{"degraded", "ok"}, ""},
+		{"second streak", 5, []string{"degraded", "ok", "degraded"}, "c"},
+		{"streak older than buffer", 3, []string{"ok", "degraded", "degraded", "degraded", "degraded"}, "c"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHealthHistory(tc.size)
+			recordStatuses(h, tc.statuses...)
+			if got := h.degradedSince(); got != tc.want {
+				t.Errorf("degradedSince = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHealthHandlerDegraded(t *testing.T) {
+	defer func(old func() map[string]string, log *healthHistory) {
+		runHealthChecks, healthLog = old, log
+	}(runHealthChecks, healthLog)
+	healthLog = newHealthHistory(10)
+	s := NewTestServer(t)
+
+	runHealthChecks = func() map[string]string { return map[string]string{"server": "ok", "sheets": "unreachable"} }
+	first := s.CheckHealth(t)
+	if first.Status != "degraded" || first.DegradedSince == "" {
+		t.Fatalf("health = %+v, want degraded with degraded_since", first)
+	}
+	if again := s.CheckHealth(t); again.DegradedSince != first.DegradedSince {
+		t.Errorf("degraded_since moved from %s to %s during one streak", first.DegradedSince, again.DegradedSince)
+	}
+
+	runHealthChecks = func() map[string]string { return map[string]string{"server": "ok"} }
+	if h := s.CheckHealth(t); h.Status != "ok" || h.DegradedSince != "" {
+		t.Errorf("health after recovery = %+v, want ok without degraded_since", h)
+	}
+
+	var history []HealthRecord
+	s.DoJSON(t, http.MethodGet, "/health/history", nil, http.StatusOK, &history)
+	var statuses []string
+	for _, r := range history {
+		statuses = append(statuses, r.Status)
+	}
+	if want := []string{"degraded", "degraded", "ok"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("history statuses = %v, want %v", statuses, want)
+	}
+}
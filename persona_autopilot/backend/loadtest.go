@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxLoadTestRPS      = 1000
+	maxLoadTestDuration = 300 // seconds
+)
+
+// loadTestEnabled exposes POST /admin/load-test. The route is not registered
+// unless AUTOPILOT_ENABLE_LOAD_TEST=true.
+var loadTestEnabled bool
+
+// loadTestRunning allows one run at a time; overlapping runs would measure
+// each other rather than the handler.
+var loadTestRunning atomic.Bool
+
+type LoadTestRequest struct {
+	RPS             int    `json:"rps" example:"50"`
+	DurationSeconds int    `json:"duration_seconds" example:"30"`
+	Persona         string `json:"persona" example:"bench"`
+	Channel         string `json:"channel" example:"stub"`
+}
+
+type LoadTestResult struct {
+	Submitted    int     `json:"submitted"`
+	Accepted     int     `json:"accepted"`
+	Rejected     int     `json:"rejected"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	P99LatencyMs float64 `json:"p99_latency_ms"`
+}
+
+// handleLoadTest submits synthetic posts through handlePost at a fixed rate
+// and responds once the run is over. The run stops early if the client goes
+// away.
+func handleLoadTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req LoadTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if req.RPS < 1 || req.RPS > maxLoadTestRPS || req.DurationSeconds < 1 || req.DurationSeconds > maxLoadTestDuration {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("rps must be 1-%d and duration_seconds 1-%d", maxLoadTestRPS, maxLoadTestDuration),
+		})
+		return
+	}
+	if req.Persona == "" {
+		req.Persona = "bench"
+	}
+	if req.Channel == "" {
+		req.Channel = "stub"
+	}
+	if !loadTestRunning.CompareAndSwap(false, true) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "load_test_in_progress"})
+		return
+	}
+	defer loadTestRunning.Store(false)
+
+	// Long runs would outlast the server's WriteTimeout; extend it for this response.
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(duration + 30*time.Second))
+
+	writeJSON(w, http.StatusOK, runLoadTest(r.Context(), req, duration))
+}
+
+// runLoadTest submits posts until duration has passed or ctx is done, then
+// waits for the submissions in flight.
+func runLoadTest(ctx context.Context, req LoadTestRequest, duration time.Duration) LoadTestResult {
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies []time.Duration
+		res       LoadTestResult
+	)
+	submit := func(seq int) {
+		defer wg.Done()
+		body, _ := json.Marshal(PostRequest{
+			Persona: req.Persona,
+			Channel: req.Channel,
+			Content: fmt.Sprintf("load test post %d", seq),
+		})
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		handlePost(rec, httptest.NewRequest(http.MethodPost, "/post", bytes.NewReader(body)))
+		elapsed := time.Since(start)
+
+		mu.Lock()
+		defer mu.Unlock()
+		latencies = append(latencies, elapsed)
+		if rec.Code == http.StatusAccepted {
+			res.Accepted++
+		} else {
+			res.Rejected++
+		}
+	}
+
+	// A ticker keeps the submission rate steady regardless of how long each
+	// post takes; a tight loop would drift with handler latency.
+	ticker := time.NewTicker(time.Second / time.Duration(req.RPS))
+	defer ticker.Stop()
+	deadline := time.After(duration)
+loop:
+	for {
+		select {
+		case <-deadline:
+			break loop
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			res.Submitted++
+			wg.Add(1)
+			go submit(res.Submitted)
+		}
+	}
+	wg.Wait()
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, l := range latencies {
+			total += l
+		}
+		res.AvgLatencyMs = millis(total / time.Duration(len(latencies)))
+		res.P99LatencyMs = millis(latencies[(len(latencies)*99-1)/100])
+	}
+	return res
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
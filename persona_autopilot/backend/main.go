@@ -1,37 +1,78 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
+	// Embedded zoneinfo, so timezones resolve on hosts without it.
+	_ "time/tzdata"
 )
 
 type PlanRequest struct {
-	Persona   string   `json:"persona"`
-	Channels  []string `json:"channels"`
-	Goal      string   `json:"goal"`
-	Timeframe string   `json:"timeframe"` // e.g., "today", "weekly"
+	Persona   string   `json:"persona" example:"brand-x"`
+	Channels  []string `json:"channels" example:"[\"twitter\",\"email\"]"`
+	Goal      string   `json:"goal" example:"awareness"`
+	Timeframe string   `json:"timeframe" example:"today"`                     // e.g., "today", "weekly"
+	Timezone  string   `json:"timezone,omitempty" example:"America/New_York"` // persona's IANA zone, default UTC
+
+	// expandedFrom[i] is the group alias Channels[i] came from, "" if none.
+	// Set by channel group expansion, never by the caller.
+	expandedFrom []string
+	// loc is Timezone resolved by validation; nil means UTC.
+	loc *time.Location
 }
 
 type PlanItem struct {
-	Channel string `json:"channel"`
-	When    string `json:"when"`   // ISO8601 string
-	Summary string `json:"summary"` // short description
+	Channel   string `json:"channel" example:"twitter"`
+	When      string `json:"when" example:"2024-01-15T14:00:00Z"`                      // ISO8601 string
+	Summary   string `json:"summary"`                                                  // short description
+	Timezone  string `json:"timezone,omitempty"`                                       // zone WhenLocal is shown in; When is always UTC
+	WhenLocal string `json:"when_local,omitempty" example:"2024-01-15T09:00:00-05:00"` // When in Timezone, with offset; derived, never stored
+
+	AffinityScore float64           `json:"affinity_score"`          // goal/channel affinity that ordered this item; 0 without data
+	TraceContext  map[string]string `json:"trace_context,omitempty"` // W3C traceparent/tracestate of the creating request
+
+	ExpandedFromGroup *string `json:"expanded_from_group"` // channel group alias that produced this item; null if listed directly
 }
 
 type PlanResponse struct {
-	Persona string     `json:"persona"`
-	Items   []PlanItem `json:"items"`
+	Persona          string     `json:"persona"`
+	Items            []PlanItem `json:"items"`
+	Stats            PlanStats  `json:"stats"`
+	ValidationPassed bool       `json:"validation_passed,omitempty"` // set on ?validate_only=true
+	RequestedPersona string     `json:"requested_persona,omitempty"` // set when an A/B test chose Persona
+	ABVariant        string     `json:"ab_variant,omitempty"`        // "a" or "b"
+	Meta             *PlanMeta  `json:"meta,omitempty"`
+}
+
+// PlanMeta carries debug details about how a response was produced.
+type PlanMeta struct {
+	AllocBytes uint64 `json:"alloc_bytes"`
+	Mallocs    uint64 `json:"mallocs"`
+}
+
+type PlanStats struct {
+	ItemCount    int      `json:"item_count"`
+	ChannelsUsed []string `json:"channels_used"`
+	EarliestWhen string   `json:"earliest_when"`
+	LatestWhen   string   `json:"latest_when"`
 }
 
 type PostRequest struct {
-	Persona string `json:"persona"`
-	Channel string `json:"channel"`
-	Content string `json:"content"`
+	Persona string `json:"persona" example:"brand-x"`
+	Channel string `json:"channel" example:"twitter"`
+	Content string `json:"content" example:"Our spring collection is live."`
 }
 
 type PostResponse struct {
@@ -40,25 +81,198 @@ type PostResponse struct {
 	Channel string `json:"channel"`
 }
 
+// planLimiter caps POST /plan requests per persona.
+var planLimiter *rateLimiter
+
+// planResponses deduplicates identical POST /plan requests. Entries age out
+// or are cleared when a config reload changes synthesis inputs; once persona
+// defaults feed synthesis, changing a profile must invalidate them too.
+var planResponses *planCache
+
+// Request size limits. Both reject with 422 and a LimitError body such as
+// {"error":"too_many_channels","max":100,"actual":10000}, documented on the
+// routes marked limited.
+var (
+	maxChannelsPerPlan int
+	maxPlanItemsStored int
+)
+
+// configure sets the globals derived from the environment. It must run
+// after validateConfig, so an out-of-range value exits with code 2 instead
+// of failing an allocation during package init.
+func configure() {
+	rejectPII = os.Getenv("AUTOPILOT_REJECT_PII") == "true"
+	allocDebugEnabled = os.Getenv("AUTOPILOT_ENABLE_ALLOC_DEBUG") == "true"
+	benchEndpointEnabled = os.Getenv("AUTOPILOT_ENABLE_BENCH_ENDPOINT") == "true"
+	loadTestEnabled = os.Getenv("AUTOPILOT_ENABLE_LOAD_TEST") == "true"
+	a, _ := parseGoalChannelAffinity(os.Getenv("AUTOPILOT_GOAL_CHANNEL_AFFINITY_JSON"))
+	goalAffinity.Store(&a)
+
+	planLimiter = newRateLimiter("persona_plan_rate_limits", envInt("AUTOPILOT_PERSONA_PLAN_RPM", 10),
+		int64(envInt("AUTOPILOT_RATE_LIMIT_CACHE_MAX_BYTES", 1<<20)))
+	planResponses = newPlanCache(30*time.Second, int64(envInt("AUTOPILOT_PLAN_CACHE_MAX_BYTES", 32<<20)))
+	maxChannelsPerPlan = envInt("AUTOPILOT_MAX_CHANNELS_PER_PLAN", 100)
+	maxPlanItemsStored = envInt("AUTOPILOT_MAX_PLAN_ITEMS_STORED", 10000)
+	healthLog = newHealthHistory(envInt("AUTOPILOT_HEALTH_HISTORY_SIZE", 100))
+}
+
+// piiDetector screens post content before it is accepted. With
+// AUTOPILOT_REJECT_PII=true matches are rejected, otherwise only logged.
+var (
+	piiDetector PIIDetector = NewRegexPIIDetector()
+	rejectPII   bool
+)
+
+// allocDebugEnabled allows X-Debug-Allocs: true on POST /plan. ReadMemStats
+// stops the world, so this must stay off in production.
+var allocDebugEnabled bool
+
 func main() {
-	addr := defaultAddr()
-	mux := http.NewServeMux()
-	mux.HandleFunc("/health", handleHealth)
-	mux.HandleFunc("/plan", handlePlan)
-	mux.HandleFunc("/post", handlePost)
+	if errs := validateConfig(); len(errs) > 0 {
+		for _, e := range errs {
+			log.Printf("config error: %v", e)
+		}
+		os.Exit(2)
+	}
+	configure()
+
+	if path := os.Getenv("AUTOPILOT_CONFIG_FILE"); path != "" {
+		// validateConfig checked the file, but it may have changed since.
+		cfg, modTime, err := readConfigFile(path)
+		if err != nil {
+			log.Printf("config error: AUTOPILOT_CONFIG_FILE=%q: %v", path, err)
+			os.Exit(2)
+		}
+		applyConfigFile(cfg)
+		go watchConfigFile(path, modTime, time.Duration(envInt("AUTOPILOT_CONFIG_RELOAD_INTERVAL_SECONDS", 30))*time.Second)
+	}
 
+	addr := defaultAddr()
 	server := &http.Server{
 		Addr:              addr,
-		Handler:           logRequests(mux),
+		Handler:           newHandler(),
 		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       envMillis("AUTOPILOT_READ_TIMEOUT_MS", 15000),
+		WriteTimeout:      envMillis("AUTOPILOT_WRITE_TIMEOUT_MS", 30000),
+		IdleTimeout:       envMillis("AUTOPILOT_IDLE_TIMEOUT_MS", 60000),
+	}
+	if server.WriteTimeout < server.ReadTimeout {
+		log.Printf("WARN write timeout %s is shorter than read timeout %s; responses to slow uploads will be cut off",
+			server.WriteTimeout, server.ReadTimeout)
 	}
 
+	if allocDebugEnabled {
+		log.Printf("WARN allocation debugging is enabled; do not run this in production")
+	}
 	log.Printf("backend listening on %s", addr)
 	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("server error: %v", err)
 	}
 }
 
+// newHandler registers routes() behind the middleware chain the server runs.
+func newHandler() http.Handler {
+	mux := http.NewServeMux()
+	registered := make(map[string]bool)
+	for _, rt := range routes() {
+		// Several methods may share a pattern and its handler.
+		if !registered[rt.pattern] {
+			mux.HandleFunc(rt.pattern, rt.handler)
+			registered[rt.pattern] = true
+		}
+	}
+	return logRequests(securityHeadersMiddleware(namingMiddleware(decompressRequestMiddleware(mux))))
+}
+
+// route is a registered endpoint. The descriptive fields feed /openapi.json,
+// so every handler should be registered here rather than on the mux directly.
+type route struct {
+	pattern     string // ServeMux pattern
+	path        string // OpenAPI path, when it differs from pattern
+	method      string
+	summary     string
+	description string   // longer OpenAPI description, if any
+	query       []string // optional string query parameters
+	request     any      // zero value of the JSON body type, if any
+	response    any      // zero value of the success body type; for NDJSON, a []any of the line types
+	contentType string   // success media type, application/json if empty
+	status      int      // success status code
+	limited     bool     // may answer 422 with a LimitError
+	handler     http.HandlerFunc
+}
+
+func routes() []route {
+	rts := []route{
+		{pattern: "/health", method: http.MethodGet, summary: "Liveness check",
+			response: HealthResponse{}, status: http.StatusOK, handler: handleHealth},
+		{pattern: "/health/history", method: http.MethodGet, summary: "Recent health check outcomes, oldest first",
+			response: []HealthRecord{}, status: http.StatusOK, handler: handleHealthHistory},
+		{pattern: "/plan", method: http.MethodPost, summary: "Synthesize a posting plan",
+			query: []string{"validate_only"}, request: PlanRequest{}, response: PlanResponse{},
+			status: http.StatusOK, limited: true, handler: handlePlan},
+		{pattern: "/plan/stream", method: http.MethodGet, summary: "Stream a synthesized plan as NDJSON",
+			description: "Takes the same body as POST /plan. Each line is a PlanStreamItem, and the last " +
+				"is a PlanStreamDone with done set to true. OpenAPI 3.0 does not support a request body " +
+				"on GET, so Swagger UI cannot send one; call this endpoint with curl or another client.",
+			request: PlanRequest{}, response: []any{PlanStreamItem{}, PlanStreamDone{}},
+			contentType: "application/x-ndjson", status: http.StatusOK, limited: true,
+			handler: handlePlanStream},
+		{pattern: "/post", method: http.MethodPost, summary: "Queue a post",
+			request: PostRequest{}, response: PostResponse{}, status: http.StatusAccepted, handler: handlePost},
+		{pattern: "/posts/validate-batch", method: http.MethodPost, summary: "Check posts without queuing them",
+			request: []PostRequest{}, response: BatchValidationResponse{}, status: http.StatusOK,
+			handler: handleValidatePostBatch},
+		{pattern: "/personas/", path: "/personas/{name}/ab-test", method: http.MethodGet,
+			summary: "Show a persona's A/B split", response: ABTest{}, status: http.StatusOK, handler: handlePersonaABTest},
+		{pattern: "/personas/", path: "/personas/{name}/ab-test", method: http.MethodPost,
+			summary: "Route part of a persona's plan requests to a variant persona", request: ABTest{},
+			response: ABTest{}, status: http.StatusOK, handler: handlePersonaABTest},
+		{pattern: "/personas/", path: "/personas/{name}/ab-test", method: http.MethodDelete,
+			summary: "Disable a persona's A/B split", status: http.StatusNoContent, handler: handlePersonaABTest},
+		{pattern: "/integrations/google-sheets/import", method: http.MethodPost,
+			summary: "Build a plan from a Google Sheet", request: SheetsImportRequest{},
+			response: PlanResponse{}, status: http.StatusOK, limited: true, handler: handleSheetsImport},
+		{pattern: "/integrations/google-sheets/columns/", path: "/integrations/google-sheets/columns/{spreadsheet_id}",
+			method: http.MethodGet, summary: "Preview a sheet's header row", query: []string{"sheet_name"},
+			response: map[string]any{}, status: http.StatusOK, handler: handleSheetsColumns},
+		{pattern: "/channel-groups", method: http.MethodGet, summary: "List channel groups",
+			response: []ChannelGroup{}, status: http.StatusOK, handler: handleChannelGroups},
+		{pattern: "/channel-groups", method: http.MethodPost, summary: "Create a channel group alias",
+			request: ChannelGroup{}, response: ChannelGroup{}, status: http.StatusCreated, limited: true,
+			handler: handleChannelGroups},
+		{pattern: "/channel-groups/", path: "/channel-groups/{name}", method: http.MethodDelete,
+			summary: "Delete a channel group", status: http.StatusNoContent, handler: handleChannelGroup},
+		{pattern: "/admin/memory-stats", method: http.MethodGet, summary: "Size and evictions of in-memory caches",
+			response: []CacheStats{}, status: http.StatusOK, handler: handleMemoryStats},
+		{pattern: "/openapi.json", method: http.MethodGet, summary: "OpenAPI 3.0 description of this API",
+			response: map[string]any{}, status: http.StatusOK, handler: handleOpenAPI},
+		{pattern: "/docs", method: http.MethodGet, summary: "Swagger UI for /openapi.json",
+			status: http.StatusOK, handler: handleDocs},
+	}
+	if benchEndpointEnabled {
+		rts = append(rts, route{pattern: "/bench/plan", method: http.MethodGet,
+			summary: "Synthesize a fixed benchmark plan", query: []string{"channels", "persona"},
+			response: PlanResponse{}, status: http.StatusOK, handler: handleBenchPlan})
+	}
+	if loadTestEnabled {
+		rts = append(rts, route{pattern: "/admin/load-test", method: http.MethodPost,
+			summary: "Submit synthetic posts at a fixed rate", request: LoadTestRequest{},
+			response: LoadTestResult{}, status: http.StatusOK, handler: handleLoadTest})
+	}
+	return rts
+}
+
+func handleMemoryStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, []CacheStats{
+		planResponses.entries.Stats(),
+		planLimiter.buckets.Stats(),
+	})
+}
+
 func defaultAddr() string {
 	if v := os.Getenv("AUTOPILOT_BACKEND_ADDR"); v != "" {
 		return v
@@ -66,8 +280,20 @@ func defaultAddr() string {
 	return ":8080"
 }
 
-func handleHealth(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+// envInt reads a positive integer from key, falling back to def when the
+// variable is unset or invalid. validateConfig rejects invalid values at
+// startup, so in practice the fallback only covers unset variables.
+func envInt(key string, def int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+// envMillis reads a positive millisecond count from key as a duration.
+func envMillis(key string, defMillis int) time.Duration {
+	return time.Duration(envInt(key, defMillis)) * time.Millisecond
 }
 
 func handlePlan(w http.ResponseWriter, r *http.Request) {
@@ -76,34 +302,216 @@ func handlePlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req PlanRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+	req, ok := decodePlanRequest(w, r)
+	if !ok {
+		return
+	}
+
+	requested := req.Persona
+	var ab abAssignment
+	req.Persona, ab = assignVariant(req.Persona)
+	trace := traceContextFrom(r)
+	// decorate adds the per-request fields, which are never cached.
+	decorate := func(resp *PlanResponse) {
+		ab.apply(resp)
+		resp.Items = withTraceContext(resp.Items, trace)
+	}
+
+	validateOnly := r.URL.Query().Get("validate_only") == "true"
+	debugAllocs := allocDebugEnabled && r.Header.Get("X-Debug-Allocs") == "true"
+	// Allocation debugging has to measure a real synthesis, so it bypasses the cache.
+	cacheKey := planCacheKey(req)
+	if !debugAllocs {
+		if cached, ok := planResponses.get(cacheKey); ok {
+			cached.ValidationPassed = validateOnly
+			decorate(&cached)
+			w.Header().Set("X-Cache", "HIT")
+			writeJSON(w, http.StatusOK, cached)
+			return
+		}
+	}
+
+	// Rate limits follow the persona the client asked for, not the variant.
+	// Dry runs create nothing, so they do not spend the persona's tokens.
+	if !validateOnly && !allowPlanRequest(w, requested) {
 		return
 	}
 
+	var meta *PlanMeta
+	var before runtime.MemStats
+	if debugAllocs {
+		runtime.ReadMemStats(&before)
+	}
 	items := synthesizePlan(req)
+	if debugAllocs {
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		meta = &PlanMeta{
+			AllocBytes: after.TotalAlloc - before.TotalAlloc,
+			Mallocs:    after.Mallocs - before.Mallocs,
+		}
+	}
+
 	resp := PlanResponse{
 		Persona: req.Persona,
 		Items:   items,
+		Stats:   computePlanStats(items),
+		Meta:    meta,
+	}
+	w.Header().Set("X-Cache", "MISS")
+	// Dry-run: synthesis has run, nothing below may have side effects.
+	if validateOnly {
+		resp.ValidationPassed = true
+		decorate(&resp)
+		writeJSON(w, http.StatusOK, resp)
+		return
 	}
+	if !debugAllocs {
+		planResponses.put(cacheKey, resp)
+	}
+	decorate(&resp)
 	writeJSON(w, http.StatusOK, resp)
 }
 
-func synthesizePlan(req PlanRequest) []PlanItem {
-	var items []PlanItem
-	now := time.Now()
-	for i, ch := range req.Channels {
-		when := now.Add(time.Duration(i) * time.Hour).UTC().Format(time.RFC3339)
-		items = append(items, PlanItem{
-			Channel: ch,
-			When:    when,
-			Summary: fmt.Sprintf("%s: %s [%s]", req.Persona, req.Goal, req.Timeframe),
-		})
+// decodePlanRequest reads a PlanRequest, expands channel groups and runs
+// the request checks shared by every plan endpoint. On failure it has
+// already written the error response.
+func decodePlanRequest(w http.ResponseWriter, r *http.Request) (PlanRequest, bool) {
+	var req PlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return req, false
+	}
+	if len(req.Channels) > maxChannelsPerPlan {
+		writeLimitError(w, "too_many_channels", maxChannelsPerPlan, len(req.Channels))
+		return req, false
+	}
+	// Group aliases are expanded first so every later check sees real
+	// channels. Expansion enforces the channel cap itself; when it stops
+	// early, actual is the count reached, not the full expansion.
+	channels, groups, err := channelGroups.expand(req.Channels, maxChannelsPerPlan)
+	if errors.Is(err, errTooManyChannels) {
+		writeLimitError(w, "too_many_channels", maxChannelsPerPlan, len(channels))
+		return req, false
+	}
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return req, false
+	}
+	req.Channels, req.expandedFrom = channels, groups
+	loc, err := validatePlanRequest(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return req, false
+	}
+	req.loc = loc
+	return req, true
+}
+
+// allowPlanRequest applies the per-persona plan rate limit, writing the 429
+// response when it is exceeded.
+func allowPlanRequest(w http.ResponseWriter, persona string) bool {
+	ok, wait := planLimiter.allow(persona)
+	if ok {
+		return true
+	}
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	log.Printf("WARN plan rate limit exceeded for persona %q", persona)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeJSON(w, http.StatusTooManyRequests, map[string]any{
+		"error":               "persona_plan_rate_exceeded",
+		"persona":             persona,
+		"retry_after_seconds": retryAfter,
+	})
+	return false
+}
+
+func writeLimitError(w http.ResponseWriter, code string, limit, actual int) {
+	writeJSON(w, http.StatusUnprocessableEntity, LimitError{Error: code, Max: limit, Actual: actual})
+}
+
+// validatePlanRequest checks req and resolves its timezone, so synthesis
+// does not have to look the zone up again.
+func validatePlanRequest(req PlanRequest) (*time.Location, error) {
+	if req.Persona == "" {
+		return nil, errors.New("persona is required")
+	}
+	if len(req.Channels) == 0 {
+		return nil, errors.New("at least one channel is required")
+	}
+	for _, ch := range req.Channels {
+		if ch == "" {
+			return nil, errors.New("channel names must be non-empty")
+		}
+	}
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", req.Timezone)
 	}
+	return loc, nil
+}
+
+func synthesizePlan(req PlanRequest) []PlanItem {
+	items := make([]PlanItem, 0, len(req.Channels))
+	synthesizePlanEach(req, func(_, _ int, item PlanItem) {
+		items = append(items, item)
+	})
 	return items
 }
 
+// synthesizePlanEach generates the plan one item at a time, calling emit
+// with the item's index and the total item count as soon as it is ready.
+func synthesizePlanEach(req PlanRequest, emit func(i, total int, item PlanItem)) {
+	// Slots are hours from now and do not depend on the zone; it only
+	// annotates each item (Timezone, WhenLocal). When is always UTC.
+	loc := req.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	order, scores := currentGoalAffinity().rankChannels(req.Goal, req.Channels)
+
+	now := time.Now().In(loc)
+	for i, idx := range order {
+		ch := req.Channels[idx]
+		at := now.Add(time.Duration(i) * time.Hour)
+		item := PlanItem{
+			Channel:   ch,
+			When:      at.UTC().Format(time.RFC3339),
+			Summary:   fmt.Sprintf("%s: %s [%s]", req.Persona, req.Goal, req.Timeframe),
+			Timezone:  loc.String(),
+			WhenLocal: at.Format(time.RFC3339),
+		}
+		if scores != nil {
+			item.AffinityScore = scores[i]
+		}
+		if idx < len(req.expandedFrom) && req.expandedFrom[idx] != "" {
+			group := req.expandedFrom[idx]
+			item.ExpandedFromGroup = &group
+		}
+		emit(i, len(order), item)
+	}
+}
+
+// computePlanStats summarizes items. When values are UTC RFC3339, so they
+// order correctly as strings.
+func computePlanStats(items []PlanItem) PlanStats {
+	stats := PlanStats{ItemCount: len(items), ChannelsUsed: []string{}}
+	seen := make(map[string]bool)
+	for _, it := range items {
+		if !seen[it.Channel] {
+			seen[it.Channel] = true
+			stats.ChannelsUsed = append(stats.ChannelsUsed, it.Channel)
+		}
+		if stats.EarliestWhen == "" || it.When < stats.EarliestWhen {
+			stats.EarliestWhen = it.When
+		}
+		if it.When > stats.LatestWhen {
+			stats.LatestWhen = it.When
+		}
+	}
+	return stats
+}
+
 func handlePost(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -115,6 +523,14 @@ func handlePost(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
 		return
 	}
+	// Never log the matched text itself, only where and what kind it is.
+	if matches := piiDetector.Detect(req.Content); len(matches) > 0 {
+		if rejectPII {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "pii_detected", "matches": matches})
+			return
+		}
+		log.Printf("WARN post for persona %q on %s contains possible PII (%s)", req.Persona, req.Channel, piiTypes(matches))
+	}
 
 	// Stub: accept and return a synthetic ID.
 	resp := PostResponse{
@@ -133,6 +549,89 @@ func logRequests(next http.Handler) http.Handler {
 	})
 }
 
+// decompressRequestMiddleware transparently inflates gzip request bodies.
+// The Content-Encoding header is dropped so handlers see a plain body.
+//
+// A small gzip body can inflate to gigabytes, so the inflated size is capped
+// at AUTOPILOT_MAX_DECOMPRESSED_BODY_BYTES (default 10 MiB) and larger
+// bodies are rejected with 413. Bodies are inflated in full before the
+// handler runs, which also turns corruption anywhere in the stream into
+// invalid_gzip_body. Any coding other than gzip and identity is answered
+// with 415 rather than handed on still compressed.
+func decompressRequestMiddleware(next http.Handler) http.Handler {
+	limit := int64(envInt("AUTOPILOT_MAX_DECOMPRESSED_BODY_BYTES", 10<<20))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gzipped, ok := requestIsGzipped(r.Header.Values("Content-Encoding"))
+		if !ok {
+			writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "unsupported_content_encoding"})
+			return
+		}
+		if !gzipped {
+			r.Header.Del("Content-Encoding")
+			next.ServeHTTP(w, r)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_gzip_body"})
+			return
+		}
+		defer zr.Close()
+		body, err := io.ReadAll(io.LimitReader(zr, limit+1))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_gzip_body"})
+			return
+		}
+		if int64(len(body)) > limit {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]any{"error": "decompressed_body_too_large", "max": limit})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Del("Content-Encoding")
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestIsGzipped interprets Content-Encoding values. Only identity and a
+// single gzip layer are supported; ok is false for anything else.
+func requestIsGzipped(values []string) (gzipped, ok bool) {
+	for _, v := range values {
+		for _, coding := range strings.Split(v, ",") {
+			switch strings.ToLower(strings.TrimSpace(coding)) {
+			case "", "identity":
+			case "gzip", "x-gzip":
+				if gzipped {
+					return false, false
+				}
+				gzipped = true
+			default:
+				return false, false
+			}
+		}
+	}
+	return gzipped, true
+}
+
+// securityHeadersMiddleware hardens responses against being rendered by a
+// browser. AUTOPILOT_CSP_HEADER replaces the default CSP for deployments
+// behind a gateway that sets its own policy.
+func securityHeadersMiddleware(next http.Handler) http.Handler {
+	csp := os.Getenv("AUTOPILOT_CSP_HEADER")
+	if csp == "" {
+		csp = "default-src 'none'"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Security-Policy", csp)
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		next.ServeHTTP(w, r)
+	})
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
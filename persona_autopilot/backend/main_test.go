@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	configure()
+	os.Exit(m.Run())
+}
+
+func TestComputePlanStats(t *testing.T) {
+	items := []PlanItem{
+		{Channel: "twitter", When: "2024-01-15T14:00:00Z"},
+		{Channel: "email", When: "2024-01-15T09:00:00Z"},
+		{Channel: "twitter", When: "2024-01-16T08:00:00Z"},
+	}
+	want := PlanStats{
+		ItemCount:    3,
+		ChannelsUsed: []string{"twitter", "email"},
+		EarliestWhen: "2024-01-15T09:00:00Z",
+		LatestWhen:   "2024-01-16T08:00:00Z",
+	}
+	if got := computePlanStats(items); !reflect.DeepEqual(got, want) {
+		t.Errorf("computePlanStats = %+v, want %+v", got, want)
+	}
+
+	empty := computePlanStats(nil)
+	if empty.ItemCount != 0 || empty.ChannelsUsed == nil || len(empty.ChannelsUsed) != 0 {
+		t.Errorf("computePlanStats(nil) = %+v, want zero count and empty channels_used", empty)
+	}
+}
+
+func TestHandlePlanStatsJSON(t *testing.T) {
+	body, _ := json.Marshal(PlanRequest{
+		Persona:   "stats-test",
+		Channels:  []string{"twitter", "email", "twitter"},
+		Goal:      "awareness",
+		Timeframe: "today",
+	})
+	rec := httptest.NewRecorder()
+	handlePlan(rec, httptest.NewRequest(http.MethodPost, "/plan", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	var stats map[string]json.RawMessage
+	if err := json.Unmarshal(raw["stats"], &stats); err != nil {
+		t.Fatalf("stats missing or not an object: %s", raw["stats"])
+	}
+	for _, key := range []string{"item_count", "channels_used", "earliest_when", "latest_when"} {
+		if _, ok := stats[key]; !ok {
+			t.Errorf("stats.%s not serialized", key)
+		}
+	}
+
+	var resp PlanResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := computePlanStats(resp.Items); !reflect.DeepEqual(resp.Stats, want) {
+		t.Errorf("stats = %+v, want %+v", resp.Stats, want)
+	}
+	if resp.Stats.ItemCount != 3 || !reflect.DeepEqual(resp.Stats.ChannelsUsed, []string{"twitter", "email"}) {
+		t.Errorf("stats = %+v, want 3 items over twitter and email", resp.Stats)
+	}
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressRequestMiddleware(t *testing.T) {
+	t.Setenv("AUTOPILOT_MAX_DECOMPRESSED_BODY_BYTES", "4096")
+	s := NewTestServer(t)
+
+	plan, _ := json.Marshal(PlanRequest{Persona: "gzip-test", Channels: []string{"twitter", "email"}, Goal: "awareness"})
+	compressed := gzipBytes(t, plan)
+	huge := gzipBytes(t, append(append([]byte{}, plan[:len(plan)-1]...), bytes.Repeat([]byte(" "), 8192)...))
+	gz := http.Header{"Content-Encoding": {"gzip"}}
+
+	tests := []struct {
+		name   string
+		body   []byte
+		header http.Header
+		want   int
+		errKey string
+	}{
+		{"gzip plan request", compressed, gz, http.StatusOK, ""},
+		{"plain plan request", plan, nil, http.StatusOK, ""},
+		{"not gzip", plan, gz, http.StatusBadRequest, "invalid_gzip_body"},
+		{"truncated stream", compressed[:len(compressed)-8], gz, http.StatusBadRequest, "invalid_gzip_body"},
+		{"inflates past the limit", huge, gz, http.StatusRequestEntityTooLarge, "decompressed_body_too_large"},
+		{"gzip with identity", compressed, http.Header{"Content-Encoding": {"gzip, identity"}}, http.StatusOK, ""},
+		{"identity", plan, http.Header{"Content-Encoding": {"identity"}}, http.StatusOK, ""},
+		{"deflate", plan, http.Header{"Content-Encoding": {"deflate"}}, http.StatusUnsupportedMediaType, "unsupported_content_encoding"},
+		{"brotli", plan, http.Header{"Content-Encoding": {"br"}}, http.StatusUnsupportedMediaType, "unsupported_content_encoding"},
+		{"gzip twice", compressed, http.Header{"Content-Encoding": {"gzip", "gzip"}}, http.StatusUnsupportedMediaType,
+			"unsupported_content_encoding"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, body := s.Do(t, http.MethodPost, "/plan", tc.body, tc.header)
+			if resp.StatusCode != tc.want {
+				t.Fatalf("status %d, want %d; body %s", resp.StatusCode, tc.want, body)
+			}
+			if tc.errKey != "" {
+				var e ErrorResponse
+				if err := json.Unmarshal(body, &e); err != nil || e.Error != tc.errKey {
+					t.Errorf("body %s, want error %q", body, tc.errKey)
+				}
+				return
+			}
+			var p PlanResponse
+			if err := json.Unmarshal(body, &p); err != nil || p.Persona != "gzip-test" || len(p.Items) != 2 {
+				t.Errorf("plan = %s, want two items for gzip-test", body)
+			}
+		})
+	}
+}
+
+func TestSynthesizePlanTimezoneIsAnnotationOnly(t *testing.T) {
+	req := PlanRequest{Persona: "tz-test", Channels: []string{"twitter", "email"}, Timezone: "Asia/Tokyo"}
+	loc, err := validatePlanRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.loc = loc
+
+	for i, it := range synthesizePlan(req) {
+		when, err := time.Parse(time.RFC3339, it.When)
+		if err != nil || when.Location() != time.UTC {
+			t.Fatalf("item %d: when = %q, want UTC RFC3339", i, it.When)
+		}
+		local, err := time.Parse(time.RFC3339, it.WhenLocal)
+		if err != nil || !local.Equal(when) || !strings.HasSuffix(it.WhenLocal, "+09:00") {
+			t.Errorf("item %d: when_local = %q, want %s in +09:00", i, it.WhenLocal, it.When)
+		}
+		if it.Timezone != "Asia/Tokyo" {
+			t.Errorf("item %d: timezone = %q", i, it.Timezone)
+		}
+	}
+
+	// Requests built without validation, such as the bench plan, fall back to UTC.
+	req.loc = nil
+	if it := synthesizePlan(req)[0]; it.Timezone != "UTC" || it.When != it.WhenLocal {
+		t.Errorf("item without loc = %+v, want UTC", it)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// namingMiddleware rewrites JSON response keys when the caller passes
+// ?naming=camel (or ?naming=snake). Struct tags stay snake_case; this is a
+// post-encode convenience for clients that want camelCase and may be removed
+// if it becomes a maintenance burden. JSON responses are buffered and
+// converted whole; NDJSON responses (GET /plan/stream) are converted line by
+// line and written through, so streaming and flushing keep working.
+//
+// /openapi.json is passed through untouched: its keys are OpenAPI vocabulary
+// and its path templates must keep matching the parameter names.
+func namingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/openapi.json" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var toCamel bool
+		switch r.URL.Query().Get("naming") {
+		case "":
+			next.ServeHTTP(w, r)
+			return
+		case "camel":
+			toCamel = true
+		case "snake":
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "naming must be snake or camel"})
+			return
+		}
+
+		bw := &bufferedResponseWriter{ResponseWriter: w, toCamel: toCamel}
+		next.ServeHTTP(bw, r)
+
+		if bw.stream {
+			// A trailing line without its newline was never written.
+			if bw.buf.Len() > 0 {
+				_, _ = w.Write(transformKeys(bw.buf.Bytes(), toCamel))
+			}
+			return
+		}
+		body := bw.buf.Bytes()
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			body = transformKeys(body, toCamel)
+			w.Header().Del("Content-Length")
+		}
+		if bw.status == 0 {
+			bw.status = http.StatusOK
+		}
+		w.WriteHeader(bw.status)
+		_, _ = w.Write(body)
+	})
+}
+
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	toCamel bool
+	status  int
+	stream  bool // NDJSON: headers are sent and complete lines written through
+	buf     bytes.Buffer
+}
+
+// WriteHeader records the status. For NDJSON it also commits the headers,
+// since the body is converted as it is written rather than at the end.
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/x-ndjson") {
+		w.stream = true
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.buf.Write(p)
+	if !w.stream {
+		return len(p), nil
+	}
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if _, err := w.ResponseWriter.Write(transformKeys(w.buf.Next(i+1), w.toCamel)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// FlushError is what http.ResponseController calls. A buffered response has
+// nothing to flush until the handler returns; flushing the underlying writer
+// then would commit its headers early.
+func (w *bufferedResponseWriter) FlushError() error {
+	if !w.stream {
+		return nil
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection.
+func (w *bufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// transformKeys converts every object key in a JSON document to camelCase
+// (toCamel) or snake_case, keeping key order. Values of map-typed fields (see
+// verbatimKeys) are copied as-is, since their keys are data rather than field
+// names. Input that is not valid JSON is returned unchanged.
+func transformKeys(data []byte, toCamel bool) []byte {
+	var out bytes.Buffer
+	if err := transformValue(&out, json.RawMessage(bytes.TrimSpace(data)), toCamel); err != nil {
+		return data
+	}
+	if bytes.HasSuffix(data, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+var errInvalidJSON = errors.New("invalid JSON")
+
+func transformValue(out *bytes.Buffer, raw json.RawMessage, toCamel bool) error {
+	if len(raw) == 0 || (raw[0] != '{' && raw[0] != '[') {
+		if !json.Valid(raw) {
+			return errInvalidJSON
+		}
+		out.Write(raw)
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil { // opening delimiter
+		return err
+	}
+	isObject := raw[0] == '{'
+	if isObject {
+		out.WriteByte('{')
+	} else {
+		out.WriteByte('[')
+	}
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if isObject {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			name := tok.(string)
+			key, _ := json.Marshal(convertKey(name, toCamel))
+			out.Write(key)
+			out.WriteByte(':')
+			if verbatimKeys()[name] {
+				var elem json.RawMessage
+				if err := dec.Decode(&elem); err != nil {
+					return err
+				}
+				out.Write(elem)
+				continue
+			}
+		}
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return err
+		}
+		if err := transformValue(out, elem, toCamel); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil { // closing delimiter
+		return err
+	}
+	if isObject {
+		out.WriteByte('}')
+	} else {
+		out.WriteByte(']')
+	}
+	return nil
+}
+
+// verbatimKeys returns the JSON names of map-typed struct fields reachable
+// from the registered response types, such as checks and trace_context.
+var verbatimKeys = sync.OnceValue(func() map[string]bool {
+	keys := map[string]bool{}
+	seen := map[reflect.Type]bool{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] {
+			return
+		}
+		seen[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name != "" && f.Type.Kind() == reflect.Map {
+				keys[name] = true
+				continue
+			}
+			walk(f.Type)
+		}
+	}
+	for _, rt := range routes() {
+		for _, t := range rt.responseTypes() {
+			walk(t)
+		}
+	}
+	return keys
+})
+
+func convertKey(key string, toCamel bool) string {
+	var b strings.Builder
+	if toCamel {
+		upper := false
+		for _, r := range key {
+			switch {
+			case r == '_':
+				upper = b.Len() > 0
+			case upper:
+				b.WriteRune(unicode.ToUpper(r))
+				upper = false
+			default:
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	}
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransformKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		toCamel bool
+		want    string
+	}{
+		{"to camel", `{"item_count":1,"channels_used":["a_b"]}`, true, `{"itemCount":1,"channelsUsed":["a_b"]}`},
+		{"to snake", `{"itemCount":1,"nested":{"latestWhen":"x"}}`, false, `{"item_count":1,"nested":{"latest_when":"x"}}`},
+		{"arrays of objects", `[{"earliest_when":"x"},{"ab_variant":"b"}]`, true, `[{"earliestWhen":"x"},{"abVariant":"b"}]`},
+		{"map fields keep their keys", `{"trace_context":{"trace_parent":"00"},"checks":{"db_pool":"ok"}}`, true,
+			`{"traceContext":{"trace_parent":"00"},"checks":{"db_pool":"ok"}}`},
+		{"invalid JSON", `{"a_b":`, true, `{"a_b":`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(transformKeys([]byte(tc.in), tc.toCamel)); got != tc.want {
+				t.Errorf("transformKeys(%s) = %s, want %s", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNamingMiddlewareSkipsOpenAPI(t *testing.T) {
+	s := NewTestServer(t)
+	for _, naming := range []string{"snake", "camel"} {
+		var spec map[string]any
+		s.DoJSON(t, http.MethodGet, "/openapi.json?naming="+naming, nil, http.StatusOK, &spec)
+		sheets, _ := spec["paths"].(map[string]any)["/integrations/google-sheets/columns/{spreadsheet_id}"].(map[string]any)
+		if sheets == nil || sheets["get"] == nil {
+			t.Errorf("?naming=%s: path template was rewritten", naming)
+		}
+		plan, _ := spec["paths"].(map[string]any)["/plan"].(map[string]any)["post"].(map[string]any)
+		if plan["requestBody"] == nil {
+			t.Errorf("?naming=%s: requestBody was rewritten", naming)
+		}
+	}
+}
+
+func TestNamingMiddlewareStreamsNDJSON(t *testing.T) {
+	h := namingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{"item_count":1}`+"\n"+`{"ab_`)
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+		}
+		// The complete line went out before the handler finished.
+		rec := w.(*bufferedResponseWriter).ResponseWriter.(*httptest.ResponseRecorder)
+		if got := rec.Body.String(); got != `{"itemCount":1}`+"\n" || !rec.Flushed {
+			t.Errorf("mid-stream body %q (flushed %v), want the first line converted", got, rec.Flushed)
+		}
+		_, _ = io.WriteString(w, `variant":"b"}`)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plan/stream?naming=camel", nil))
+	if got, want := rec.Body.String(), `{"itemCount":1}`+"\n"+`{"abVariant":"b"}`; got != want {
+		t.Errorf("body %q, want %q", got, want)
+	}
+
+	s := NewTestServer(t)
+	resp, data := s.Do(t, http.MethodGet, "/plan/stream?naming=camel", PlanRequest{
+		Persona: "naming-stream", Channels: []string{"twitter", "email"},
+	}, nil)
+	if resp.StatusCode != http.StatusOK || strings.Count(string(data), `"whenLocal"`) != 2 ||
+		!strings.Contains(string(data), `"itemCount":2`) || strings.Contains(string(data), `"when_local"`) {
+		t.Errorf("status %d, body %s; want camelCase keys on every line", resp.StatusCode, data)
+	}
+}
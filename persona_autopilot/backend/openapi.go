@@ -0,0 +1,297 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// swaggerUIDist is the pinned Swagger UI bundle /docs loads from a CDN.
+const swaggerUIDist = "https://unpkg.com/swagger-ui-dist@5.17.14"
+
+// docsScript boots Swagger UI against this server's spec. It is inline, so
+// the /docs CSP allows it by hash rather than with 'unsafe-inline'.
+const docsScript = `window.onload = function () {
+  SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+};`
+
+var docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>persona-autopilot API</title>
+<link rel="stylesheet" href="` + swaggerUIDist + `/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="` + swaggerUIDist + `/swagger-ui-bundle.js"></script>
+<script>` + docsScript + `</script>
+</body>
+</html>
+`
+
+// docsCSP relaxes the default policy for /docs only: the CDN bundle, the
+// inline boot script, the inline styles Swagger UI sets, and same-origin
+// fetches of /openapi.json.
+var docsCSP = func() string {
+	sum := sha256.Sum256([]byte(docsScript))
+	return "default-src 'none'; " +
+		"script-src " + swaggerUIDist + "/ 'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'; " +
+		"style-src " + swaggerUIDist + "/ 'unsafe-inline'; " +
+		"img-src 'self' data:; connect-src 'self'"
+}()
+
+// ErrorResponse is the body of every non-2xx JSON response. Some errors add
+// fields (for example max/actual on limit errors).
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// LimitError is the 422 body for requests over a size limit. Callers over
+// the limit are expected to split the request.
+type LimitError struct {
+	Error  string `json:"error" example:"too_many_channels"`
+	Max    int    `json:"max" example:"100"`
+	Actual int    `json:"actual" example:"10000"`
+}
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, buildOpenAPI(routes()))
+}
+
+// handleDocs serves a Swagger UI page for /openapi.json. The page is served
+// from this origin so the spec is fetched same-origin, without CORS or
+// mixed-content problems.
+func handleDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Security-Policy", docsCSP)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, docsPage)
+}
+
+// buildOpenAPI describes rts as an OpenAPI 3.0 document. Schemas come from
+// the Go types via reflection over their json tags.
+func buildOpenAPI(rts []route) map[string]any {
+	sb := &schemaBuilder{components: map[string]any{}}
+	errRef := sb.schemaFor(reflect.TypeOf(ErrorResponse{}))
+	limitRef := sb.schemaFor(reflect.TypeOf(LimitError{}))
+
+	paths := map[string]any{}
+	for _, rt := range rts {
+		op := map[string]any{"summary": rt.summary}
+		if rt.description != "" {
+			op["description"] = rt.description
+		}
+
+		var params []any
+		for _, q := range rt.query {
+			params = append(params, map[string]any{
+				"name": q, "in": "query", "required": false,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		path := rt.pattern
+		if rt.path != "" {
+			path = rt.path
+			for _, seg := range strings.Split(path, "/") {
+				if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+					params = append(params, map[string]any{
+						"name": strings.Trim(seg, "{}"), "in": "path", "required": true,
+						"schema": map[string]any{"type": "string"},
+					})
+				}
+			}
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if rt.request != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(sb.schemaFor(reflect.TypeOf(rt.request))),
+			}
+		}
+
+		success := map[string]any{"description": http.StatusText(rt.status)}
+		if types := rt.responseTypes(); len(types) > 0 {
+			schema := sb.schemaFor(types[0])
+			if len(types) > 1 {
+				var oneOf []any
+				for _, t := range types {
+					oneOf = append(oneOf, sb.schemaFor(t))
+				}
+				schema = map[string]any{"oneOf": oneOf}
+			}
+			contentType := rt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			success["content"] = map[string]any{contentType: map[string]any{"schema": schema}}
+		}
+		responses := map[string]any{
+			strconv.Itoa(rt.status): success,
+			"default": map[string]any{
+				"description": "Error",
+				"content":     jsonContent(errRef),
+			},
+		}
+		if rt.limited {
+			responses[strconv.Itoa(http.StatusUnprocessableEntity)] = map[string]any{
+				"description": "Validation failed; size limit errors carry max and actual",
+				"content":     jsonContent(map[string]any{"oneOf": []any{limitRef, errRef}}),
+			}
+		}
+		op["responses"] = responses
+
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "persona-autopilot backend",
+			"version": "0.1.0",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": sb.components},
+	}
+}
+
+// responseTypes lists the success body types of rt: one for a JSON body, or
+// each line type of an NDJSON stream.
+func (rt route) responseTypes() []reflect.Type {
+	if lines, ok := rt.response.([]any); ok {
+		types := make([]reflect.Type, len(lines))
+		for i, l := range lines {
+			types[i] = reflect.TypeOf(l)
+		}
+		return types
+	}
+	if rt.response == nil {
+		return nil
+	}
+	return []reflect.Type{reflect.TypeOf(rt.response)}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// exampleValue interprets an `example` struct tag: JSON literals are used
+// as-is, anything else is a plain string.
+func exampleValue(tag string) any {
+	var v any
+	if err := json.Unmarshal([]byte(tag), &v); err == nil {
+		return v
+	}
+	return tag
+}
+
+type schemaBuilder struct {
+	components map[string]any
+}
+
+// schemaFor returns a schema for t. Named structs are emitted once under
+// components/schemas and referenced from everywhere else. Pointers are
+// nullable, since encoding/json writes a nil pointer as null.
+func (b *schemaBuilder) schemaFor(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		s := b.schemaFor(t.Elem())
+		if _, ok := s["$ref"]; ok {
+			// OpenAPI 3.0 ignores siblings of $ref, so wrap it.
+			return map[string]any{"allOf": []any{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string", "example": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean", "example": true}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "example": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number", "example": 0.0}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = map[string]any{} // guards recursive types
+			b.components[t.Name()] = b.structSchema(t)
+		}
+		return ref
+	default: // interfaces: any JSON value
+		return map[string]any{}
+	}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			// Embedded structs contribute their fields inline, as in encoding/json.
+			embedded := b.structSchema(f.Type)
+			for k, v := range embedded["properties"].(map[string]any) {
+				props[k] = v
+			}
+			if req, ok := embedded["required"].([]string); ok {
+				required = append(required, req...)
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := b.schemaFor(f.Type)
+		if ex, ok := f.Tag.Lookup("example"); ok && prop["$ref"] == nil {
+			prop["example"] = exampleValue(ex)
+		}
+		props[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
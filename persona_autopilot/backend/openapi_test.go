@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIPointersAreNullable(t *testing.T) {
+	spec := buildOpenAPI(routes())
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	item := schemas["PlanItem"].(map[string]any)
+	prop := item["properties"].(map[string]any)["expanded_from_group"].(map[string]any)
+	if prop["type"] != "string" || prop["nullable"] != true {
+		t.Errorf("expanded_from_group = %v, want a nullable string", prop)
+	}
+
+	meta := schemas["PlanResponse"].(map[string]any)["properties"].(map[string]any)["meta"].(map[string]any)
+	if meta["nullable"] != true || meta["allOf"] == nil || meta["$ref"] != nil {
+		t.Errorf("meta = %v, want a nullable allOf wrapping the PlanMeta ref", meta)
+	}
+}
+
+func TestDocsPage(t *testing.T) {
+	s := NewTestServer(t)
+	resp, body := s.Do(t, http.MethodGet, "/docs", nil, nil)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), `url: "/openapi.json"`) {
+		t.Errorf("page does not load /openapi.json:\n%s", body)
+	}
+	csp := resp.Header.Get("Content-Security-Policy")
+	for _, want := range []string{"connect-src 'self'", "'sha256-", swaggerUIDist} {
+		if !strings.Contains(csp, want) {
+			t.Errorf("CSP %q lacks %q", csp, want)
+		}
+	}
+
+	// Only /docs is relaxed.
+	resp, _ = s.Do(t, http.MethodGet, "/health", nil, nil)
+	if csp := resp.Header.Get("Content-Security-Policy"); csp != "default-src 'none'" {
+		t.Errorf("/health CSP = %q, want the default", csp)
+	}
+}
+
+func TestOpenAPILimitErrors(t *testing.T) {
+	spec := buildOpenAPI(routes())
+	paths := spec["paths"].(map[string]any)
+	for _, rt := range routes() {
+		path := rt.pattern
+		if rt.path != "" {
+			path = rt.path
+		}
+		op := paths[path].(map[string]any)[strings.ToLower(rt.method)].(map[string]any)
+		_, has422 := op["responses"].(map[string]any)["422"]
+		if has422 != rt.limited {
+			t.Errorf("%s %s: 422 documented = %v, want %v", rt.method, path, has422, rt.limited)
+		}
+	}
+	limit := spec["components"].(map[string]any)["schemas"].(map[string]any)["LimitError"].(map[string]any)
+	props := limit["properties"].(map[string]any)
+	if props["max"] == nil || props["actual"] == nil {
+		t.Errorf("LimitError = %v, want max and actual", limit)
+	}
+}
+
+func TestOpenAPIPlanStream(t *testing.T) {
+	spec := buildOpenAPI(routes())
+	op := spec["paths"].(map[string]any)["/plan/stream"].(map[string]any)["get"].(map[string]any)
+	content := op["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)
+	if content["application/json"] != nil {
+		t.Errorf("content types %v, want only application/x-ndjson", content)
+	}
+	ndjson, _ := content["application/x-ndjson"].(map[string]any)
+	oneOf, _ := ndjson["schema"].(map[string]any)["oneOf"].([]any)
+	var refs []string
+	for _, s := range oneOf {
+		refs = append(refs, s.(map[string]any)["$ref"].(string))
+	}
+	if strings.Join(refs, " ") != "#/components/schemas/PlanStreamItem #/components/schemas/PlanStreamDone" {
+		t.Errorf("line schemas = %v, want PlanStreamItem and PlanStreamDone", refs)
+	}
+	if desc, _ := op["description"].(string); !strings.Contains(desc, "Swagger UI") {
+		t.Errorf("description %q does not explain the GET body", desc)
+	}
+
+	plan := spec["paths"].(map[string]any)["/plan"].(map[string]any)["post"].(map[string]any)
+	if plan["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)["application/json"] == nil {
+		t.Error("POST /plan no longer documents application/json")
+	}
+}
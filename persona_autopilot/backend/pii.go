@@ -0,0 +1,59 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PIIMatch locates a suspected piece of personal data by byte offset. It
+// deliberately carries no copy of the matched text.
+type PIIMatch struct {
+	Type   string `json:"type" example:"email"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+}
+
+type PIIDetector interface {
+	Detect(content string) []PIIMatch
+}
+
+// RegexPIIDetector flags emails (simplified RFC 5322), E.164 phone numbers
+// and SSN-like numbers. It favours recall over precision.
+type RegexPIIDetector struct {
+	patterns []piiPattern
+}
+
+type piiPattern struct {
+	typ string
+	re  *regexp.Regexp
+}
+
+func NewRegexPIIDetector() *RegexPIIDetector {
+	return &RegexPIIDetector{patterns: []piiPattern{
+		{"email", regexp.MustCompile(`[A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)+`)},
+		{"phone", regexp.MustCompile(`\+[1-9][0-9]{6,14}\b`)},
+		{"ssn", regexp.MustCompile(`\b[0-9]{3}-[0-9]{2}-[0-9]{4}\b`)},
+	}}
+}
+
+// Detect returns all matches ordered by offset.
+func (d *RegexPIIDetector) Detect(content string) []PIIMatch {
+	var matches []PIIMatch
+	for _, p := range d.patterns {
+		for _, loc := range p.re.FindAllStringIndex(content, -1) {
+			matches = append(matches, PIIMatch{Type: p.typ, Offset: loc[0], Length: loc[1] - loc[0]})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Offset < matches[j].Offset })
+	return matches
+}
+
+// piiTypes summarizes matches for logging without exposing the text.
+func piiTypes(matches []PIIMatch) string {
+	types := make([]string, len(matches))
+	for i, m := range matches {
+		types[i] = m.Type
+	}
+	return strings.Join(types, ",")
+}
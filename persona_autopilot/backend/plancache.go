@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+	"unsafe"
+)
+
+// planCache remembers recent PlanResponses so identical requests arriving
+// within the TTL are not synthesized twice. Responses are shared between
+// hits and must not be mutated. Expired entries are dropped when read or
+// when the LRU budget pushes them out.
+type planCache struct {
+	ttl     time.Duration
+	entries *BoundedCache[string, planCacheEntry]
+	now     func() time.Time
+}
+
+type planCacheEntry struct {
+	resp    PlanResponse
+	expires time.Time
+}
+
+func newPlanCache(ttl time.Duration, maxBytes int64) *planCache {
+	return &planCache{
+		ttl:     ttl,
+		entries: NewBoundedCache("plan_responses", maxBytes, planCacheEntrySize),
+		now:     time.Now,
+	}
+}
+
+// planCacheKey hashes the request's JSON encoding, which is canonical
+// because struct fields always marshal in declaration order. Group
+// provenance is included so "@social" and its spelled-out channels don't
+// share an entry.
+func planCacheKey(req PlanRequest) string {
+	b, _ := json.Marshal(struct {
+		PlanRequest
+		ExpandedFrom []string `json:"expanded_from"`
+	}{req, req.expandedFrom})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *planCache) get(key string) (PlanResponse, bool) {
+	e, ok := c.entries.Get(key)
+	if !ok {
+		return PlanResponse{}, false
+	}
+	if !c.now().Before(e.expires) {
+		c.entries.Delete(key)
+		return PlanResponse{}, false
+	}
+	return e.resp, true
+}
+
+func (c *planCache) put(key string, resp PlanResponse) {
+	c.entries.Put(key, planCacheEntry{resp: resp, expires: c.now().Add(c.ttl)})
+}
+
+// clear drops every cached response, for when synthesis inputs change.
+func (c *planCache) clear() {
+	c.entries.Clear()
+}
+
+// planCacheEntrySize estimates the memory held by a cached response: the
+// fixed struct sizes plus the string and slice contents they point to.
+func planCacheEntrySize(key string, e planCacheEntry) int64 {
+	n := int64(len(key)) + int64(unsafe.Sizeof(e)) + int64(len(e.resp.Persona))
+	for _, it := range e.resp.Items {
+		n += int64(unsafe.Sizeof(it)) + int64(len(it.Channel)+len(it.When)+len(it.Summary)+len(it.Timezone)+len(it.WhenLocal))
+		if it.ExpandedFromGroup != nil {
+			n += int64(len(*it.ExpandedFromGroup))
+		}
+	}
+	for _, ch := range e.resp.Stats.ChannelsUsed {
+		n += int64(unsafe.Sizeof(ch)) + int64(len(ch))
+	}
+	return n + int64(len(e.resp.Stats.EarliestWhen)+len(e.resp.Stats.LatestWhen))
+}
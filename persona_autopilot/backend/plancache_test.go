@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanCacheTTL(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	c := newPlanCache(30*time.Second, 1<<20)
+	c.now = func() time.Time { return now }
+
+	key := planCacheKey(PlanRequest{Persona: "ttl", Channels: []string{"email"}})
+	c.put(key, PlanResponse{Persona: "ttl"})
+
+	now = now.Add(29 * time.Second)
+	if resp, ok := c.get(key); !ok || resp.Persona != "ttl" {
+		t.Fatalf("get before expiry = %+v, %v; want a hit", resp, ok)
+	}
+	// Expiry is exclusive: at exactly the TTL the entry is gone.
+	now = now.Add(time.Second)
+	if _, ok := c.get(key); ok {
+		t.Error("hit at the TTL, want a miss")
+	}
+	if st := c.entries.Stats(); st.Entries != 0 || st.Evictions != 0 {
+		t.Errorf("stats = %+v, want the expired entry deleted without counting an eviction", st)
+	}
+
+	// A re-put restarts the clock.
+	c.put(key, PlanResponse{Persona: "ttl"})
+	now = now.Add(29 * time.Second)
+	if _, ok := c.get(key); !ok {
+		t.Error("miss after re-put, want a hit")
+	}
+}
+
+func TestPlanCacheKeyIncludesGroupProvenance(t *testing.T) {
+	req := PlanRequest{Persona: "key", Channels: []string{"twitter", "instagram"}}
+	grouped := req
+	grouped.expandedFrom = []string{"social", "social"}
+	if planCacheKey(req) == planCacheKey(grouped) {
+		t.Error("a group expansion shares a key with the spelled-out channels")
+	}
+}
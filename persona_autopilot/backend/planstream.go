@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type StreamProgress struct {
+	Current int `json:"current"`
+	Total   int `json:"total"`
+}
+
+// PlanStreamItem is one NDJSON line of GET /plan/stream: the item's own
+// fields plus its position in the plan.
+type PlanStreamItem struct {
+	PlanItem
+	Progress StreamProgress `json:"progress"`
+}
+
+// PlanStreamDone is the final line of GET /plan/stream.
+type PlanStreamDone struct {
+	Done             bool      `json:"done"`
+	Persona          string    `json:"persona"`
+	RequestedPersona string    `json:"requested_persona,omitempty"`
+	ABVariant        string    `json:"ab_variant,omitempty"`
+	Stats            PlanStats `json:"stats"`
+}
+
+// handlePlanStream takes the same body as POST /plan but writes each item as
+// an NDJSON line as soon as it is synthesized, so clients can render the
+// schedule before the whole plan is ready. Results are not cached.
+func handlePlanStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, ok := decodePlanRequest(w, r)
+	if !ok {
+		return
+	}
+	requested := req.Persona
+	var ab abAssignment
+	req.Persona, ab = assignVariant(req.Persona)
+	if !allowPlanRequest(w, requested) {
+		return
+	}
+	trace := traceContextFrom(r)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+
+	var items []PlanItem
+	synthesizePlanEach(req, func(i, total int, item PlanItem) {
+		item.TraceContext = trace
+		items = append(items, item)
+		_ = enc.Encode(PlanStreamItem{
+			PlanItem: item,
+			Progress: StreamProgress{Current: i + 1, Total: total},
+		})
+		_ = rc.Flush()
+	})
+
+	_ = enc.Encode(PlanStreamDone{
+		Done:             true,
+		Persona:          req.Persona,
+		RequestedPersona: ab.requested,
+		ABVariant:        ab.variant,
+		Stats:            computePlanStats(items),
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// rateLimiter is an in-memory token bucket per key. Each bucket holds up to
+// perMinute tokens and refills continuously. Buckets live in a BoundedCache;
+// evicting one only forgives that key's recent usage.
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	buckets   *BoundedCache[string, *tokenBucket]
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(name string, perMinute int, maxBytes int64) *rateLimiter {
+	return &rateLimiter{
+		perMinute: float64(perMinute),
+		buckets: NewBoundedCache(name, maxBytes, func(key string, _ *tokenBucket) int64 {
+			return int64(len(key)) + int64(unsafe.Sizeof(tokenBucket{}))
+		}),
+		now: time.Now,
+	}
+}
+
+// allow takes a token for key. When none is available it reports how long
+// until the next one.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets.Get(key)
+	if !ok {
+		b = &tokenBucket{tokens: l.perMinute, last: now}
+		l.buckets.Put(key, b)
+	}
+	b.tokens = math.Min(l.perMinute, b.tokens+now.Sub(b.last).Minutes()*l.perMinute)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.perMinute * float64(time.Minute))
+	return false, wait
+}
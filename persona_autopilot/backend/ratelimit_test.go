@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefill(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	l := newRateLimiter("test", 2, 1<<20)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("p"); !ok {
+			t.Fatalf("request %d denied within the burst", i+1)
+		}
+	}
+	ok, wait := l.allow("p")
+	if ok || wait != 30*time.Second {
+		t.Fatalf("third request = %v, wait %s; want denied for 30s", ok, wait)
+	}
+	if ok, _ := l.allow("other"); !ok {
+		t.Error("a different key shared the exhausted bucket")
+	}
+
+	now = now.Add(29 * time.Second)
+	if ok, _ := l.allow("p"); ok {
+		t.Error("allowed before a full token refilled")
+	}
+	// The denied call above spent nothing, so one more second completes a token.
+	now = now.Add(time.Second)
+	if ok, _ := l.allow("p"); !ok {
+		t.Error("denied after a token refilled")
+	}
+
+	// Idle time refills up to the burst size, not beyond.
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("p"); !ok {
+			t.Fatalf("request %d after idling denied", i+1)
+		}
+	}
+	if ok, _ := l.allow("p"); ok {
+		t.Error("bucket refilled past perMinute")
+	}
+}
+
+// exhaustPlanLimit spends persona's plan tokens with distinct channels so
+// no request is served from the plan cache.
+func exhaustPlanLimit(t *testing.T, s *TestServer, persona string) {
+	t.Helper()
+	for i := 0; i < envInt("AUTOPILOT_PERSONA_PLAN_RPM", 10); i++ {
+		s.PlanRequest(t, PlanRequest{Persona: persona, Channels: []string{fmt.Sprintf("limit-%d", i)}})
+	}
+}
+
+func TestPlanRateLimitResponse(t *testing.T) {
+	s := NewTestServer(t)
+	exhaustPlanLimit(t, s, "limit-body")
+
+	resp, body := s.Do(t, http.MethodPost, "/plan", PlanRequest{Persona: "limit-body", Channels: []string{"email"}}, nil)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429; body %s", resp.StatusCode, body)
+	}
+	if n, err := strconv.Atoi(resp.Header.Get("Retry-After")); err != nil || n < 1 {
+		t.Errorf("Retry-After = %q, want a positive number of seconds", resp.Header.Get("Retry-After"))
+	}
+	var e struct {
+		Error             string `json:"error"`
+		Persona           string `json:"persona"`
+		RetryAfterSeconds int    `json:"retry_after_seconds"`
+	}
+	if err := json.Unmarshal(body, &e); err != nil || e.Error != "persona_plan_rate_exceeded" ||
+		e.Persona != "limit-body" || strconv.Itoa(e.RetryAfterSeconds) != resp.Header.Get("Retry-After") {
+		t.Errorf("body %s, want persona_plan_rate_exceeded for limit-body matching Retry-After", body)
+	}
+
+	// Other personas keep their own budget.
+	s.PlanRequest(t, PlanRequest{Persona: "limit-body-other", Channels: []string{"email"}})
+}
+
+func TestPlanRateLimitFollowsRequestedPersona(t *testing.T) {
+	abTests.set("limit-ab", ABTest{VariantBName: "limit-ab-b", SplitPercent: 100})
+	defer abTests.remove("limit-ab")
+	s := NewTestServer(t)
+
+	exhaustPlanLimit(t, s, "limit-ab")
+	var e map[string]any
+	s.DoJSON(t, http.MethodPost, "/plan", PlanRequest{Persona: "limit-ab", Channels: []string{"email"}},
+		http.StatusTooManyRequests, &e)
+	if e["persona"] != "limit-ab" {
+		t.Errorf("429 names persona %v, want the requested limit-ab", e["persona"])
+	}
+
+	// Every request above was served by the variant, yet its own budget is untouched.
+	s.PlanRequest(t, PlanRequest{Persona: "limit-ab-b", Channels: []string{"email"}})
+}
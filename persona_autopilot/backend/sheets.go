@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// sheetsAPIBase is a variable so tests can point it at a fake.
+var sheetsAPIBase = "https://sheets.googleapis.com/v4/spreadsheets"
+
+// maxSheetResponseBytes bounds a Sheets API response. Row counts are
+// bounded by the requested range, but cells are not.
+const maxSheetResponseBytes = 32 << 20
+
+var sheetsClient = &http.Client{Timeout: 10 * time.Second}
+
+type SheetsImportRequest struct {
+	SpreadsheetID string `json:"spreadsheet_id"`
+	SheetName     string `json:"sheet_name"`
+	Persona       string `json:"persona"`
+}
+
+type SheetRowError struct {
+	Row   int    `json:"row"` // 1-based row number as shown in the sheet
+	Error string `json:"error"`
+}
+
+// sheetValues is the subset of the Sheets API v4 ValueRange we use.
+type sheetValues struct {
+	Values [][]string `json:"values"`
+}
+
+func handleSheetsImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SheetsImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if req.SpreadsheetID == "" || req.SheetName == "" || req.Persona == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "spreadsheet_id, sheet_name and persona are required"})
+		return
+	}
+
+	// Only the header and one row past the limit are fetched; that extra row
+	// is enough to tell the sheet is too large.
+	rows, err := fetchSheetValues(req.SpreadsheetID, req.SheetName, maxPlanItemsStored+2)
+	if err != nil {
+		writeSheetsError(w, err)
+		return
+	}
+
+	// The header row is not an item. Rows past the fetched range are not
+	// counted, so actual is at most maxPlanItemsStored+1.
+	if n := len(rows) - 1; n > maxPlanItemsStored {
+		writeLimitError(w, "too_many_plan_items", maxPlanItemsStored, n)
+		return
+	}
+
+	items, rowErrs := planItemsFromRows(req.Persona, rows)
+	if len(rowErrs) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid rows", "rows": rowErrs})
+		return
+	}
+	writeJSON(w, http.StatusOK, PlanResponse{
+		Persona: req.Persona,
+		Items:   items,
+		Stats:   computePlanStats(items),
+	})
+}
+
+func handleSheetsColumns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/integrations/google-sheets/columns/")
+	if id == "" || strings.Contains(id, "/") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "spreadsheet_id is required"})
+		return
+	}
+
+	// Without a sheet name the range applies to the first sheet.
+	rng := "1:1"
+	if name := r.URL.Query().Get("sheet_name"); name != "" {
+		rng = quoteSheetName(name) + "!1:1"
+	}
+	rows, err := fetchSheetRange(id, rng)
+	if err != nil {
+		writeSheetsError(w, err)
+		return
+	}
+
+	columns := []string{}
+	if len(rows) > 0 {
+		columns = rows[0]
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"spreadsheet_id": id, "columns": columns})
+}
+
+// planItemsFromRows maps a sheet (header row first) onto plan items. The
+// channel and when columns are required; summary falls back to the persona.
+func planItemsFromRows(persona string, rows [][]string) ([]PlanItem, []SheetRowError) {
+	if len(rows) == 0 {
+		return nil, []SheetRowError{{Row: 1, Error: "sheet is empty"}}
+	}
+
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, name := range []string{"channel", "when"} {
+		if _, ok := col[name]; !ok {
+			return nil, []SheetRowError{{Row: 1, Error: fmt.Sprintf("missing %q column", name)}}
+		}
+	}
+	cell := func(row []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var items []PlanItem
+	var errs []SheetRowError
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+		ch := cell(row, "channel")
+		if ch == "" {
+			errs = append(errs, SheetRowError{Row: rowNum, Error: "channel is empty"})
+			continue
+		}
+		when, err := time.Parse(time.RFC3339, cell(row, "when"))
+		if err != nil {
+			errs = append(errs, SheetRowError{Row: rowNum, Error: "when must be an RFC3339 timestamp"})
+			continue
+		}
+		summary := cell(row, "summary")
+		if summary == "" {
+			summary = persona
+		}
+		items = append(items, PlanItem{
+			Channel: ch,
+			When:    when.UTC().Format(time.RFC3339),
+			Summary: summary,
+		})
+	}
+	if len(items) == 0 && len(errs) == 0 {
+		errs = append(errs, SheetRowError{Row: 2, Error: "sheet has no data rows"})
+	}
+	return items, errs
+}
+
+type sheetsError struct {
+	status int
+	msg    string
+}
+
+func (e *sheetsError) Error() string { return e.msg }
+
+// fetchSheetValues reads the first maxRows rows of a sheet.
+func fetchSheetValues(spreadsheetID, sheetName string, maxRows int) ([][]string, error) {
+	return fetchSheetRange(spreadsheetID, fmt.Sprintf("%s!1:%d", quoteSheetName(sheetName), maxRows))
+}
+
+func fetchSheetRange(spreadsheetID, rng string) ([][]string, error) {
+	key := os.Getenv("AUTOPILOT_GOOGLE_API_KEY")
+	if key == "" {
+		return nil, &sheetsError{http.StatusServiceUnavailable, "google sheets integration is not configured"}
+	}
+
+	u := fmt.Sprintf("%s/%s/values/%s?key=%s",
+		sheetsAPIBase, url.PathEscape(spreadsheetID), url.PathEscape(rng), url.QueryEscape(key))
+	resp, err := sheetsClient.Get(u)
+	if err != nil {
+		return nil, &sheetsError{http.StatusBadGateway, "google sheets request failed"}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &sheetsError{http.StatusNotFound, "spreadsheet or sheet not found"}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &sheetsError{http.StatusBadGateway, fmt.Sprintf("google sheets returned %d", resp.StatusCode)}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSheetResponseBytes+1))
+	if err != nil {
+		return nil, &sheetsError{http.StatusBadGateway, "google sheets request failed"}
+	}
+	if len(body) > maxSheetResponseBytes {
+		return nil, &sheetsError{http.StatusBadGateway, "google sheets response too large"}
+	}
+	var v sheetValues
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, &sheetsError{http.StatusBadGateway, "invalid google sheets response"}
+	}
+	return v.Values, nil
+}
+
+// quoteSheetName quotes a sheet name for A1 notation, e.g. 'Q1 plan'.
+func quoteSheetName(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+func writeSheetsError(w http.ResponseWriter, err error) {
+	var se *sheetsError
+	if errors.As(err, &se) {
+		writeJSON(w, se.status, map[string]string{"error": se.msg})
+		return
+	}
+	writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+}
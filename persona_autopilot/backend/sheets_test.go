@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSheetsImportFetchesBoundedRange(t *testing.T) {
+	var gotRange string
+	rows := [][]string{{"channel", "when"}}
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, gotRange, _ = strings.Cut(r.URL.Path, "/values/")
+		_ = json.NewEncoder(w).Encode(sheetValues{Values: rows})
+	}))
+	defer fake.Close()
+	defer func(base string, limit int) { sheetsAPIBase, maxPlanItemsStored = base, limit }(sheetsAPIBase, maxPlanItemsStored)
+	sheetsAPIBase, maxPlanItemsStored = fake.URL, 2
+	t.Setenv("AUTOPILOT_GOOGLE_API_KEY", "test-key")
+	s := NewTestServer(t)
+	req := SheetsImportRequest{SpreadsheetID: "abc", SheetName: "Q1 plan", Persona: "brand-x"}
+
+	rows = append(rows, []string{"twitter", "2024-01-15T14:00:00Z"}, []string{"email", "2024-01-15T15:00:00Z"})
+	var plan PlanResponse
+	s.DoJSON(t, http.MethodPost, "/integrations/google-sheets/import", req, http.StatusOK, &plan)
+	if len(plan.Items) != 2 {
+		t.Errorf("imported %d items, want 2", len(plan.Items))
+	}
+	// The header plus one row past the limit.
+	if want := "'Q1 plan'!1:4"; gotRange != want {
+		t.Errorf("requested range %q, want %q", gotRange, want)
+	}
+
+	rows = append(rows, []string{"sms", "2024-01-15T16:00:00Z"})
+	var e LimitError
+	s.DoJSON(t, http.MethodPost, "/integrations/google-sheets/import", req, http.StatusUnprocessableEntity, &e)
+	if e != (LimitError{Error: "too_many_plan_items", Max: 2, Actual: 3}) {
+		t.Errorf("limit error = %+v", e)
+	}
+}
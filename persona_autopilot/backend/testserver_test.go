@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestServer runs the full middleware chain and route table on an
+// httptest.Server. Its helpers fail the test on transport errors, unexpected
+// statuses and undecodable bodies, so tests only state what they check.
+type TestServer struct {
+	*httptest.Server
+}
+
+// NewTestServer starts a server that is closed when t finishes. Routes that
+// depend on feature flags are registered according to the flags' values at
+// the time of the call.
+func NewTestServer(t *testing.T) *TestServer {
+	t.Helper()
+	s := &TestServer{httptest.NewServer(newHandler())}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Do sends body to path and returns the response with its body read. A
+// []byte or string body is sent as-is, nil sends no body, and anything else
+// is encoded as JSON.
+func (s *TestServer) Do(t *testing.T, method, path string, body any, header http.Header) (*http.Response, []byte) {
+	t.Helper()
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		r = bytes.NewReader(b)
+	case string:
+		r = bytes.NewReader([]byte(b))
+	default:
+		enc, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+		r = bytes.NewReader(enc)
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	// Redirects are part of what handlers return, so they are not followed.
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: reading body: %v", method, path, err)
+	}
+	return resp, data
+}
+
+// DoJSON is Do for endpoints expected to answer status with a JSON body,
+// which is decoded into out unless out is nil.
+func (s *TestServer) DoJSON(t *testing.T, method, path string, body any, status int, out any) {
+	t.Helper()
+	resp, data := s.Do(t, method, path, body, nil)
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s: status %d, want %d; body %s", method, path, resp.StatusCode, status, data)
+	}
+	if out == nil {
+		return
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatalf("%s %s: decoding %s: %v", method, path, data, err)
+	}
+}
+
+// PlanRequest synthesizes a plan with POST /plan, expecting 200.
+func (s *TestServer) PlanRequest(t *testing.T, req PlanRequest) PlanResponse {
+	t.Helper()
+	var resp PlanResponse
+	s.DoJSON(t, http.MethodPost, "/plan", req, http.StatusOK, &resp)
+	return resp
+}
+
+// PostContent queues a post with POST /post, expecting 202.
+func (s *TestServer) PostContent(t *testing.T, req PostRequest) PostResponse {
+	t.Helper()
+	var resp PostResponse
+	s.DoJSON(t, http.MethodPost, "/post", req, http.StatusAccepted, &resp)
+	return resp
+}
+
+// CheckHealth calls GET /health, expecting 200.
+func (s *TestServer) CheckHealth(t *testing.T) HealthResponse {
+	t.Helper()
+	var resp HealthResponse
+	s.DoJSON(t, http.MethodGet, "/health", nil, http.StatusOK, &resp)
+	return resp
+}
+
+// handlerCase is one row of a table-driven handler test.
+type handlerCase struct {
+	name   string
+	method string
+	path   string
+	body   any
+	want   int
+}
+
+func runHandlerCases(t *testing.T, s *TestServer, cases []handlerCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, data := s.Do(t, tc.method, tc.path, tc.body, nil)
+			if resp.StatusCode != tc.want {
+				t.Errorf("%s %s: status %d, want %d; body %s", tc.method, tc.path, resp.StatusCode, tc.want, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// traceparentRe matches a W3C Trace Context traceparent header value.
+var traceparentRe = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// W3C limits on tracestate. The value is copied onto every plan item, so an
+// unbounded header would multiply the response size by the channel count.
+const (
+	maxTracestateLen     = 512
+	maxTracestateMembers = 32
+)
+
+// traceContextFrom extracts the W3C trace headers from r. A missing or
+// malformed traceparent yields nil, and tracestate is meaningless without it.
+// A tracestate over the spec's limits is dropped, as the spec allows.
+func traceContextFrom(r *http.Request) map[string]string {
+	tp := r.Header.Get("traceparent")
+	if !validTraceparent(tp) {
+		return nil
+	}
+	tc := map[string]string{"traceparent": tp}
+	// Repeated tracestate headers combine into one comma-separated list.
+	if ts := strings.Join(r.Header.Values("tracestate"), ","); validTracestate(ts) {
+		tc["tracestate"] = ts
+	}
+	return tc
+}
+
+// validTraceparent rejects the values the spec forbids: version ff and
+// all-zero trace or parent IDs.
+func validTraceparent(tp string) bool {
+	m := traceparentRe.FindStringSubmatch(tp)
+	if m == nil {
+		return false
+	}
+	return m[1] != "ff" && strings.Trim(m[2], "0") != "" && strings.Trim(m[3], "0") != ""
+}
+
+func validTracestate(ts string) bool {
+	if ts == "" || len(ts) > maxTracestateLen {
+		return false
+	}
+	members := 0
+	for _, m := range strings.Split(ts, ",") {
+		if strings.TrimSpace(m) != "" {
+			members++
+		}
+	}
+	return members > 0 && members <= maxTracestateMembers
+}
+
+// withTraceContext returns a copy of items carrying tc, leaving the input
+// (which may be shared through the plan cache) untouched.
+func withTraceContext(items []PlanItem, tc map[string]string) []PlanItem {
+	if tc == nil {
+		return items
+	}
+	out := make([]PlanItem, len(items))
+	for i, it := range items {
+		it.TraceContext = tc
+		out[i] = it
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validTP = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTraceContextFrom(t *testing.T) {
+	members := make([]string, 33)
+	for i := range members {
+		members[i] = fmt.Sprintf("v%d=x", i)
+	}
+	tests := []struct {
+		name        string
+		traceparent string
+		tracestate  []string
+		want        map[string]string
+	}{
+		{"missing", "", nil, nil},
+		{"valid", validTP, nil, map[string]string{"traceparent": validTP}},
+		{"uppercase hex", strings.ToUpper(validTP), nil, nil},
+		{"version ff", "ff" + validTP[2:], nil, nil},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", nil, nil},
+		{"zero parent id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", nil, nil},
+		{"tracestate kept", validTP, []string{"congo=t61rcWkgMzE"},
+			map[string]string{"traceparent": validTP, "tracestate": "congo=t61rcWkgMzE"}},
+		{"repeated tracestate headers", validTP, []string{"a=1", "b=2"},
+			map[string]string{"traceparent": validTP, "tracestate": "a=1,b=2"}},
+		{"tracestate without traceparent", "", []string{"a=1"}, nil},
+		{"tracestate over 512 chars", validTP, []string{"a=" + strings.Repeat("x", 511)},
+			map[string]string{"traceparent": validTP}},
+		{"tracestate with 33 members", validTP, []string{strings.Join(members, ",")},
+			map[string]string{"traceparent": validTP}},
+		{"tracestate with 32 members", validTP, []string{strings.Join(members[:32], ",")},
+			map[string]string{"traceparent": validTP, "tracestate": strings.Join(members[:32], ",")}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/plan", nil)
+			if tc.traceparent != "" {
+				r.Header.Set("traceparent", tc.traceparent)
+			}
+			for _, ts := range tc.tracestate {
+				r.Header.Add("tracestate", ts)
+			}
+			if got := traceContextFrom(r); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("traceContextFrom = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlanItemsCarryTraceContext(t *testing.T) {
+	s := NewTestServer(t)
+	req := PlanRequest{Persona: "trace-test", Channels: []string{"twitter", "email"}}
+	header := http.Header{"Traceparent": {validTP}, "Tracestate": {"a=" + strings.Repeat("x", 600)}}
+
+	_, body := s.Do(t, http.MethodPost, "/plan", req, header)
+	if strings.Count(string(body), validTP) != 2 || strings.Contains(string(body), "tracestate") {
+		t.Errorf("body %s, want traceparent on both items and the oversized tracestate dropped", body)
+	}
+	// The cached copy stays free of the first request's trace context.
+	if plan := s.PlanRequest(t, req); plan.Items[0].TraceContext != nil {
+		t.Errorf("untraced request got %v", plan.Items[0].TraceContext)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type PostValidationResult struct {
+	Index    int      `json:"index"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type BatchValidationResponse struct {
+	Valid   int                    `json:"valid"`
+	Invalid int                    `json:"invalid"`
+	Items   []PostValidationResult `json:"items"` // only posts with errors or warnings
+}
+
+// handleValidatePostBatch runs the checks POST /post applies to each entry and
+// reports per-item results. It is advisory: nothing is queued or logged.
+func handleValidatePostBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var posts []PostRequest
+	if err := json.NewDecoder(r.Body).Decode(&posts); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+
+	resp := BatchValidationResponse{Items: []PostValidationResult{}}
+	for i, p := range posts {
+		res := PostValidationResult{Index: i}
+		if len(piiDetector.Detect(p.Content)) > 0 {
+			// Mirrors handlePost: PII only rejects when AUTOPILOT_REJECT_PII=true.
+			if rejectPII {
+				res.Errors = append(res.Errors, "pii_detected")
+			} else {
+				res.Warnings = append(res.Warnings, "pii_detected")
+			}
+		}
+
+		if len(res.Errors) > 0 {
+			resp.Invalid++
+		} else {
+			resp.Valid++
+		}
+		if len(res.Errors) > 0 || len(res.Warnings) > 0 {
+			resp.Items = append(resp.Items, res)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}